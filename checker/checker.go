@@ -4,14 +4,26 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/google/go-github/github"
+	"github.com/sourcegraph/go-diff/diff"
 	"github.com/tengattack/unified-ci/checker/worker"
+	"github.com/tengattack/unified-ci/checks/benchmark"
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/checks/license"
+	"github.com/tengattack/unified-ci/checks/outdated"
+	"github.com/tengattack/unified-ci/checks/sast"
+	"github.com/tengattack/unified-ci/checks/sbom"
+	"github.com/tengattack/unified-ci/checks/secretscan"
 	"github.com/tengattack/unified-ci/checks/tester"
 	"github.com/tengattack/unified-ci/checks/vulnerability"
+	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
 	"github.com/tengattack/unified-ci/common"
+	"github.com/tengattack/unified-ci/store"
 	"github.com/tengattack/unified-ci/util"
 )
 
@@ -57,14 +69,18 @@ func (t *testCheckRun) Run(ctx context.Context, testName string, testConfig util
 		result, err = t.runner.Run(ctx, testName, testConfig)
 
 		title := ""
-		if testConfig.Coverage == "" {
+		if result.Conclusion == "timed_out" {
+			title = "timed out"
+		} else if result.Violation != "" {
+			title = result.Violation
+		} else if testConfig.Coverage == "" {
 			title = result.Conclusion
 		} else {
 			title = "coverage: " + result.ReportMessage
 		}
 		if ref.IsBranch() {
 			state := "success"
-			if result.Conclusion == "failure" {
+			if result.Conclusion == "failure" || result.Conclusion == "timed_out" {
 				state = "error"
 			}
 			err := ref.UpdateState(client, outputTitle, state, targetURL, title)
@@ -90,8 +106,18 @@ func (t *testCheckRun) Run(ctx context.Context, testName string, testConfig util
 
 			if checkRunID != 0 {
 				ts := github.Timestamp{Time: time.Now()}
+				summary := "```\n" + result.OutputSummary + "\n```"
+				if result.ArtifactsURL != "" {
+					summary += "\n\nArtifacts: [" + util.ArtifactsName(testName) + "](" + result.ArtifactsURL + ")"
+				}
+				annotations := result.Annotations
+				if len(annotations) > 50 {
+					// TODO: push all
+					annotations = annotations[:50]
+					common.LogAccess.Warn("Too many annotations to push them all at once. Only 50 annotations will be pushed right now.")
+				}
 				err := UpdateCheckRun(ctx, client, gpull, checkRunID, outputTitle, result.Conclusion, ts,
-					title, "```\n"+result.OutputSummary+"\n```", nil)
+					title, summary, annotations)
 				if err != nil {
 					common.LogError.Errorf("report test results to github failed: %v", err)
 					// PASS
@@ -102,6 +128,45 @@ func (t *testCheckRun) Run(ctx context.Context, testName string, testConfig util
 	return
 }
 
+func (t *testCheckRun) Skip(ctx context.Context, testName string, testConfig util.TestsConfig, reason string) (result *tester.Result) {
+	t.Log(func(w io.Writer) {
+		t.runner.LogDivider = util.NewLogDivider(false, w)
+		result = t.runner.Skip(ctx, testName, testConfig, reason)
+		reportSkippedTest(ctx, t.runner.Client, t.runner.Pull, t.runner.Ref, t.runner.TargetURL,
+			testName+" test", result.OutputSummary, w)
+	})
+	return
+}
+
+// reportSkippedTest reports the skipped test to github, so that the required checks still resolve
+func reportSkippedTest(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	targetURL, outputTitle, outputSummary string, w io.Writer) {
+	if ref.IsBranch() {
+		err := ref.UpdateState(client, outputTitle, "success", targetURL, "skipped")
+		if err != nil {
+			msg := fmt.Sprintf("Update commit state %s failed: %v", outputTitle, err)
+			_, _ = io.WriteString(w, msg+"\n")
+			common.LogError.Error(msg)
+			// PASS
+		}
+		return
+	}
+	checkRun, err := CreateCheckRun(ctx, client, gpull, outputTitle, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", outputTitle, err)
+		_, _ = io.WriteString(w, msg+"\n")
+		common.LogError.Error(msg)
+		return
+	}
+	ts := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRun.GetID(), outputTitle, "skipped", ts,
+		"skipped", outputSummary, nil)
+	if err != nil {
+		common.LogError.Errorf("report skipped test to github failed: %v", err)
+		// PASS
+	}
+}
+
 // TestCheckRun run tests and report the test results to github
 func TestCheckRun(ctx context.Context, repoPath string, client *github.Client, gpull *github.PullRequest,
 	ref common.GithubRef, targetURL string,
@@ -121,9 +186,12 @@ func TestCheckRun(ctx context.Context, repoPath string, client *github.Client, g
 	return
 }
 
-// VulnerabilityCheckRun checks and reports package vulnerability.
+// VulnerabilityCheckRun checks and reports package vulnerability, the pull
+// requests only fail for the vulnerabilities introduced or worsened by them,
+// compared with the base commit. The vulnerabilities ignored by the project
+// are reported but do not fail the check until their suppressions expire.
 func VulnerabilityCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
-	repoPath string, targetURL string, log io.Writer) (int, error) {
+	repoPath string, config util.VulnerabilityConfig, targetURL string, log io.Writer) (int, error) {
 	const checkName = "vulnerability"
 	var checkRunID int64
 
@@ -147,7 +215,7 @@ func VulnerabilityCheckRun(ctx context.Context, client *github.Client, gpull *gi
 		}
 	}
 
-	data, err := vulnerability.CheckVulnerability(ref.RepoName, repoPath, ref.Sha, ref.CheckRef)
+	data, err := checkCommitVulnerability(ctx, ref, repoPath, ref.Sha)
 	if err != nil {
 		msg := fmt.Sprintf("checks package vulnerability failed: %v", err)
 		_, _ = io.WriteString(log, msg+"\n")
@@ -169,11 +237,14 @@ func VulnerabilityCheckRun(ctx context.Context, client *github.Client, gpull *gi
 	}
 
 	if ref.IsBranch() {
+		failing, suppressed := vulnerability.Partition(vulnerability.Suppress(data, config.Ignore, time.Now()))
 		state := "success"
 		title := "no vulnerabilities"
-		if len(data) > 0 {
+		if len(failing) > 0 {
 			state = "error"
-			title = fmt.Sprintf("%d problem(s) found.", len(data))
+			title = fmt.Sprintf("%d problem(s) found.", len(failing))
+		} else if len(suppressed) > 0 {
+			title = fmt.Sprintf("%d suppressed", len(suppressed))
 		}
 		err := ref.UpdateState(client, checkName, state, targetURL, title)
 		if err != nil {
@@ -182,36 +253,694 @@ func VulnerabilityCheckRun(ctx context.Context, client *github.Client, gpull *gi
 			common.LogError.Error(msg)
 			// PASS
 		}
+		return len(failing), nil
+	}
+
+	// only the vulnerabilities introduced by the pull request fail the check
+	introduced, existing := data, []vulcommon.Data(nil)
+	message := ""
+	baseSHA, base, err := baseVulnerabilities(ctx, client, gpull, ref, repoPath)
+	if err != nil {
+		msg := fmt.Sprintf("checks package vulnerability of base failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS: report all of them
+		message = "Failed to check the vulnerabilities of base, all of them are reported.\n\n"
 	} else {
-		if checkRunID == 0 {
-			checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
-			if err != nil {
-				msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
-				_, _ = io.WriteString(log, msg+"\n")
-				common.LogError.Error(msg)
-				return 0, err
+		introduced, existing = vulnerability.Diff(data, base)
+	}
+
+	if checkRunID == 0 {
+		checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+		if err != nil {
+			msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+			_, _ = io.WriteString(log, msg+"\n")
+			common.LogError.Error(msg)
+			return 0, err
+		}
+		checkRunID = checkRun.GetID()
+	}
+
+	// the pre-existing ones fail as well once their suppressions expire
+	now := time.Now()
+	introduced, suppressed := vulnerability.Partition(vulnerability.Suppress(introduced, config.Ignore, now))
+	var expired, remained []vulcommon.Data
+	for _, d := range vulnerability.Suppress(existing, config.Ignore, now) {
+		if d.Suppressed() {
+			suppressed = append(suppressed, d)
+		} else if d.Suppression != nil {
+			expired = append(expired, d)
+		} else {
+			remained = append(remained, d)
+		}
+	}
+	problems := len(introduced) + len(expired)
+
+	conclusion := "success"
+	title := "no vulnerabilities"
+	if problems > 0 {
+		conclusion = "failure"
+		title = fmt.Sprintf("%d problem(s) found.", problems)
+	} else if len(remained)+len(suppressed) > 0 {
+		title = "no new vulnerabilities"
+	}
+	sections := []struct {
+		Header string
+		Data   []vulcommon.Data
+	}{
+		{"**Introduced by this pull request**", introduced},
+		{"**Suppressions expired**", expired},
+		{fmt.Sprintf("**Already existing in base %s**", baseSHA), remained},
+	}
+	for _, section := range sections {
+		if len(section.Data) == 0 {
+			continue
+		}
+		if message != "" {
+			message += "\n"
+		}
+		message += section.Header + ":\n\n" + vulnerabilitiesTable(section.Data)
+	}
+	if len(suppressed) > 0 {
+		if message != "" {
+			message += "\n"
+		}
+		message += suppressedTable(suppressed)
+	}
+	if message == "" {
+		message = "no vulnerabilities"
+	}
+
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRunID, checkName, conclusion, t, title, message, nil)
+	if err != nil {
+		msg := fmt.Sprintf("report package vulnerability to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return 0, err
+	}
+	return problems, nil
+}
+
+// vulnerabilitiesTable formats the vulnerabilities into a markdown table
+func vulnerabilitiesTable(data []vulcommon.Data) string {
+	table := data[0].MDTitle()
+	for _, v := range data {
+		table += v.MDTableRow()
+	}
+	return table
+}
+
+// suppressedTable formats the suppressed vulnerabilities into a collapsed
+// markdown table
+func suppressedTable(data []vulcommon.Data) string {
+	return fmt.Sprintf("<details><summary>%d suppressed vulnerabilities</summary>\n\n", len(data)) +
+		vulnerabilitiesTable(data) + "\n</details>\n"
+}
+
+// checkCommitVulnerability checks the package files of the commit extracted
+// into a temporary dir, so that the scanning running concurrently with the
+// tests is not affected by them rewriting the files in the checkout
+func checkCommitVulnerability(ctx context.Context, ref common.GithubRef, repoPath, sha string) ([]vulcommon.Data, error) {
+	dir, err := ioutil.TempDir("", "unified-ci-packages-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	err = util.ExtractFiles(ref, repoPath, sha, dir, vulnerability.PackageFiles())
+	if err != nil {
+		return nil, fmt.Errorf("extract package files failed: %v", err)
+	}
+	return vulnerability.CheckVulnerability(ctx, ref.RepoName, dir, sha, ref.CheckRef)
+}
+
+// baseVulnerabilities checks the package files of the base commit of the pull
+// request along with the head, so that both of them are compared against the
+// same advisories. Otherwise the new advisories of the unchanged dependencies
+// would be taken as introduced by the pull request.
+func baseVulnerabilities(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string) (string, []vulcommon.Data, error) {
+	baseSHA, err := util.GetBaseSHA(ctx, client, ref.Owner, ref.RepoName, gpull.GetNumber())
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot get BaseSHA: %v", err)
+	}
+
+	base, err := checkCommitVulnerability(ctx, ref, repoPath, baseSHA)
+	if err != nil {
+		return baseSHA, nil, err
+	}
+	return baseSHA, base, nil
+}
+
+// LicenseCheckRun checks the licenses of the dependencies against the allow
+// and deny lists, the pull requests only fail for the dependencies they add.
+// It returns the number of the violations.
+func LicenseCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string, config util.LicenseConfig, targetURL string, log io.Writer) (int, error) {
+	const checkName = "license"
+
+	reportError := func(err error) {
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Error(err.Error())
+		if ref.IsBranch() {
+			erro := ref.UpdateState(client, checkName, "error", targetURL, "failed to check licenses")
+			if erro != nil {
+				common.LogError.Errorf("Update commit state %s failed: %v", checkName, erro)
+				// PASS
 			}
-			checkRunID = checkRun.GetID()
+			return
+		}
+		checkRun, erro := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+		if erro != nil {
+			common.LogError.Errorf("Creating %s check run failed: %v", checkName, erro)
+			return
+		}
+		UpdateCheckRunWithError(ctx, client, gpull, checkRun.GetID(), checkName, checkName, err)
+	}
+
+	results, err := license.Resolve(repoPath)
+	if err != nil {
+		err = fmt.Errorf("resolve licenses failed: %v", err)
+		reportError(err)
+		return 0, err
+	}
+	violations, unknown := license.Check(results, config)
+
+	if ref.IsBranch() {
+		state := "success"
+		title := fmt.Sprintf("%d dependencies checked", len(results))
+		if len(violations) > 0 {
+			state = "error"
+			title = fmt.Sprintf("%d problem(s) found.", len(violations))
+		}
+		err = ref.UpdateState(client, checkName, state, targetURL, title)
+		if err != nil {
+			msg := fmt.Sprintf("Update commit state %s failed: %v", checkName, err)
+			_, _ = io.WriteString(log, msg+"\n")
+			common.LogError.Error(msg)
+			// PASS
+		}
+		return len(violations), nil
+	}
+
+	introduced, existing := violations, []license.Violation(nil)
+	message := ""
+	baseSHA, base, err := baseDependencies(ctx, client, gpull, ref, repoPath, log)
+	if err != nil {
+		msg := fmt.Sprintf("inventory dependencies of base failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS: report all of them
+		message = "Failed to inventory the dependencies of base, all of the violations are reported.\n\n"
+	} else {
+		introduced, existing = license.Diff(violations, base)
+	}
+
+	conclusion := "success"
+	title := fmt.Sprintf("%d dependencies checked", len(results))
+	if len(introduced) > 0 {
+		conclusion = "failure"
+		title = fmt.Sprintf("%d problem(s) found.", len(introduced))
+		message += "**Introduced by this pull request**:\n\n" + licenseTable(introduced)
+	}
+	if len(existing) > 0 {
+		if message != "" {
+			message += "\n"
+		}
+		message += fmt.Sprintf("**Already existing in base %s**:\n\n", baseSHA) + licenseTable(existing)
+	}
+	if len(unknown) > 0 {
+		if message != "" {
+			message += "\n"
+		}
+		table := license.Violation{}.MDTitle()
+		for _, r := range unknown {
+			table += license.Violation{Result: r}.MDTableRow()
+		}
+		message += fmt.Sprintf("<details><summary>%d dependencies with unknown licenses</summary>\n\n", len(unknown)) +
+			table + "\n</details>\n"
+	}
+	if message == "" {
+		message = "no violations"
+	}
+	_, _ = io.WriteString(log, message+"\n")
+
+	checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return len(introduced), err
+	}
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRun.GetID(), checkName, conclusion, t, title, message, nil)
+	if err != nil {
+		msg := fmt.Sprintf("report licenses to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS
+	}
+	return len(introduced), nil
+}
+
+// licenseTable formats the license violations into a markdown table
+func licenseTable(violations []license.Violation) string {
+	table := violations[0].MDTitle()
+	for _, v := range violations {
+		table += v.MDTableRow()
+	}
+	return table
+}
+
+// baseDependencies inventories the dependencies of the base commit of the
+// pull request in a worktree of base.
+func baseDependencies(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string, log io.Writer) (string, []dependency.Dependency, error) {
+	baseSHA, err := util.GetBaseSHA(ctx, client, ref.Owner, ref.RepoName, gpull.GetNumber())
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot get BaseSHA: %v", err)
+	}
+	worktreePath, err := tester.AddBaseWorktree(ref, repoPath, baseSHA, log)
+	if err != nil {
+		return baseSHA, nil, fmt.Errorf("failed to create worktree of base: %v", err)
+	}
+	defer tester.RemoveBaseWorktree(ref, repoPath, worktreePath, log)
+
+	deps, err := dependency.Inventory(worktreePath)
+	return baseSHA, deps, err
+}
+
+// SecretScanCheckRun detects the secrets in the lines added by the pull
+// request, the findings are reported as the failure annotations with the
+// secrets redacted. It returns the number of the findings.
+func SecretScanCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	diffs []*diff.FileDiff, config util.SecretScanConfig, targetURL string, log io.Writer) (int, error) {
+	const checkName = "secret-scan"
+
+	checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return 0, err
+	}
+
+	scanner, err := secretscan.NewScanner(config)
+	if err != nil {
+		err = fmt.Errorf("create secret scanner failed: %v", err)
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Error(err.Error())
+		UpdateCheckRunWithError(ctx, client, gpull, checkRun.GetID(), checkName, checkName, err)
+		return 0, err
+	}
+	findings := scanner.Scan(diffs)
+
+	conclusion := "success"
+	title := "no secrets found"
+	message := "no secrets found in the added lines"
+	var annotations []*github.CheckRunAnnotation
+	if len(findings) > 0 {
+		conclusion = "failure"
+		title = fmt.Sprintf("%d secret(s) found.", len(findings))
+		message = "Remove the secrets and revoke them, they are leaked once pushed.\n\n"
+		for _, f := range findings {
+			// only the redacted secrets are logged
+			message += "- " + f.String() + "\n"
+			annotations = append(annotations, f.Annotation())
 		}
+		if len(annotations) > 50 {
+			// TODO: push all
+			annotations = annotations[:50]
+			common.LogAccess.Warn("Too many annotations to push them all at once. Only 50 annotations will be pushed right now.")
+		}
+	}
+	_, _ = io.WriteString(log, message+"\n")
+
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRun.GetID(), checkName, conclusion, t, title, message, annotations)
+	if err != nil {
+		msg := fmt.Sprintf("report secrets to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS
+	}
+	return len(findings), nil
+}
+
+// SecurityCheckRun runs the static security analysis on the Go and PHP
+// sources, the whole repo for the branches and only the changed lines for the
+// pull requests like the linters. It returns the number of the high severity
+// findings, which fail the check.
+func SecurityCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string, diffs []*diff.FileDiff, config util.SecurityConfig, ignoredPath []string,
+	targetURL string, log io.Writer) (int, error) {
+	const checkName = "security"
 
-		conclusion := "success"
-		message := "no vulnerabilities"
-		if len(data) > 0 {
-			conclusion = "failure"
-			message = data[0].MDTitle()
-			for _, v := range data {
-				message += v.MDTableRow()
+	reportError := func(err error) {
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Error(err.Error())
+		if ref.IsBranch() {
+			erro := ref.UpdateState(client, checkName, "error", targetURL, "failed to analyze")
+			if erro != nil {
+				common.LogError.Errorf("Update commit state %s failed: %v", checkName, erro)
+				// PASS
 			}
+			return
+		}
+		checkRun, erro := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+		if erro != nil {
+			common.LogError.Errorf("Creating %s check run failed: %v", checkName, erro)
+			return
+		}
+		UpdateCheckRunWithError(ctx, client, gpull, checkRun.GetID(), checkName, checkName, err)
+	}
+
+	findings, err := sast.Check(repoPath, config, log)
+	if err != nil {
+		err = fmt.Errorf("security analysis failed: %v", err)
+		reportError(err)
+		return 0, err
+	}
+	if !ref.IsBranch() {
+		findings = sast.FilterDiff(findings, diffs)
+	}
+	var (
+		reported []sast.Finding
+		high     int
+	)
+	for _, f := range findings {
+		if util.MatchAny(ignoredPath, f.Path) {
+			continue
+		}
+		reported = append(reported, f)
+		if f.Severity == sast.High {
+			high++
+		}
+	}
+
+	title := "no problems found"
+	if len(reported) > 0 {
+		title = fmt.Sprintf("%d problem(s) found.", high)
+		if high < len(reported) {
+			title = fmt.Sprintf("%d problem(s) found, %d warning(s).", high, len(reported)-high)
 		}
+	}
+	message := ""
+	var annotations []*github.CheckRunAnnotation
+	for _, f := range reported {
+		message += "- " + f.String() + "\n"
+		annotations = append(annotations, f.Annotation())
+	}
+	if message == "" {
+		message = "no problems found"
+	}
+	_, _ = io.WriteString(log, message+"\n")
 
-		t := github.Timestamp{Time: time.Now()}
-		err = UpdateCheckRun(ctx, client, gpull, checkRunID, checkName, conclusion, t, conclusion, message, nil)
+	if ref.IsBranch() {
+		state := "success"
+		if high > 0 {
+			state = "error"
+		}
+		err = ref.UpdateState(client, checkName, state, targetURL, title)
 		if err != nil {
-			msg := fmt.Sprintf("report package vulnerability to github failed: %v", err)
+			msg := fmt.Sprintf("Update commit state %s failed: %v", checkName, err)
 			_, _ = io.WriteString(log, msg+"\n")
 			common.LogError.Error(msg)
-			return 0, err
+			// PASS
+		}
+		return high, nil
+	}
+
+	checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return high, err
+	}
+	conclusion := "success"
+	if high > 0 {
+		conclusion = "failure"
+	}
+	if len(annotations) > 50 {
+		// TODO: push all
+		annotations = annotations[:50]
+		common.LogAccess.Warn("Too many annotations to push them all at once. Only 50 annotations will be pushed right now.")
+	}
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRun.GetID(), checkName, conclusion, t, title, message, annotations)
+	if err != nil {
+		msg := fmt.Sprintf("report security findings to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS
+	}
+	return high, nil
+}
+
+// OutdatedCheckRun reports the direct dependencies far behind their latest
+// versions in the registry mirrors. It is informational, the check run is
+// neutral if there is any, and the table of them is returned.
+func OutdatedCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string, config util.OutdatedConfig, targetURL string, log io.Writer) (string, error) {
+	const checkName = "outdated"
+
+	checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return "", err
+	}
+
+	registry := outdated.Registry{SectionRegistry: common.Conf.Registry}
+	packages, err := outdated.Check(repoPath, registry, config)
+	if err != nil {
+		err = fmt.Errorf("check outdated dependencies failed: %v", err)
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Error(err.Error())
+		UpdateCheckRunWithError(ctx, client, gpull, checkRun.GetID(), checkName, checkName, err)
+		return "", err
+	}
+
+	conclusion := "success"
+	title := "dependencies are up to date"
+	message := "no outdated dependencies"
+	table := ""
+	if len(packages) > 0 {
+		conclusion = "neutral"
+		title = fmt.Sprintf("%d outdated dependencies", len(packages))
+		table = packages[0].MDTitle()
+		for _, p := range packages {
+			table += p.MDTableRow()
 		}
+		message = table
+	}
+	_, _ = io.WriteString(log, message+"\n")
+
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRun.GetID(), checkName, conclusion, t, title, message, nil)
+	if err != nil {
+		msg := fmt.Sprintf("report outdated dependencies to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS
+	}
+	return table, nil
+}
+
+// GenerateSBOM generates the SBOM documents of the dependencies of the commit,
+// and saves them in its artifacts.
+func GenerateSBOM(ref common.GithubRef, repoPath string, log io.Writer) error {
+	deps, err := dependency.Inventory(repoPath)
+	if err != nil {
+		return err
+	}
+	doc := sbom.Document{
+		Name:         ref.Owner + "/" + ref.RepoName,
+		Version:      ref.Sha,
+		Dependencies: deps,
+		Created:      time.Now(),
+		ToolName:     common.AppName,
+		ToolVersion:  common.GetVersion(),
+	}
+	dir := util.ArtifactsDir(common.Conf.Core.LogsDir, ref.Owner, ref.RepoName, ref.Sha)
+	if _, err = doc.Save(dir); err != nil {
+		return err
+	}
+	_, _ = io.WriteString(log, fmt.Sprintf("SBOM of %d dependencies saved.\n", len(deps)))
+	return nil
+}
+
+// BenchmarkCheckRun compares the benchmarks of the pull request with base, and
+// reports the comparison to github, it returns the number of the regressions.
+func BenchmarkCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string, config util.BenchmarksConfig, cache util.CacheConfig, targetURL string, log io.Writer) (int, error) {
+	const checkName = "benchmark"
+
+	checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return 0, err
+	}
+	checkRunID := checkRun.GetID()
+
+	baseSHA, err := util.GetBaseSHA(ctx, client, ref.Owner, ref.RepoName, gpull.GetNumber())
+	if err != nil {
+		err = fmt.Errorf("cannot get BaseSHA: %v", err)
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Error(err.Error())
+		UpdateCheckRunWithError(ctx, client, gpull, checkRunID, checkName, checkName, err)
+		return 0, err
+	}
+	ref.BaseSha = baseSHA
+
+	comparisons, err := tester.CompareBenchmarks(ctx, ref, baseSHA, config, repoPath, cache, log)
+	if err != nil {
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Errorf("compare benchmarks failed: %v", err)
+		UpdateCheckRunWithError(ctx, client, gpull, checkRunID, checkName, checkName, err)
+		return 0, err
+	}
+
+	regressions := benchmark.Regressions(comparisons)
+	conclusion := "success"
+	title := "no regressions"
+	if regressions > 0 {
+		conclusion = "failure"
+		title = fmt.Sprintf("%d regression(s) found.", regressions)
+	}
+	message := "no benchmarks to compare"
+	if len(comparisons) > 0 {
+		message = benchmark.MDTable(comparisons)
+		message += fmt.Sprintf("\nA significant (p < %.2f) change worse than %.2f%% is a regression.\n",
+			benchmark.Alpha, config.Threshold)
+	}
+	_, _ = io.WriteString(log, message+"\n")
+
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRunID, checkName, conclusion, t, title, message, nil)
+	if err != nil {
+		msg := fmt.Sprintf("report benchmarks to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS
+	}
+	return regressions, nil
+}
+
+// SizeCheckRun measures the size of the build outputs and saves them, the sizes
+// of pull requests are compared with base, it returns the number of the
+// outputs which grow too much and the delta table.
+func SizeCheckRun(ctx context.Context, client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
+	repoPath string, config util.SizeConfig, targetURL string, log io.Writer) (int, string, error) {
+	const checkName = "size"
+
+	reportError := func(err error) {
+		_, _ = io.WriteString(log, err.Error()+"\n")
+		common.LogError.Error(err.Error())
+		if ref.IsBranch() {
+			erro := ref.UpdateState(client, checkName, "error", targetURL, "failed to measure sizes")
+			if erro != nil {
+				common.LogError.Errorf("Update commit state %s failed: %v", checkName, erro)
+				// PASS
+			}
+			return
+		}
+		checkRun, erro := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+		if erro != nil {
+			common.LogError.Errorf("Creating %s check run failed: %v", checkName, erro)
+			return
+		}
+		UpdateCheckRunWithError(ctx, client, gpull, checkRun.GetID(), checkName, checkName, err)
+	}
+
+	sizes, err := util.MeasureSizes(repoPath, config.Files)
+	if err != nil {
+		err = fmt.Errorf("measure sizes failed: %v", err)
+		reportError(err)
+		return 0, "", err
+	}
+	var total int64
+	for name, size := range sizes {
+		total += size
+		c := store.CommitsSize{
+			Owner: ref.Owner,
+			Repo:  ref.RepoName,
+			Sha:   ref.Sha,
+			Name:  name,
+			Size:  size,
+		}
+		if err := c.Save(); err != nil {
+			msg := fmt.Sprintf("Error: %v. Failed to save %v\n", err, c)
+			_, _ = io.WriteString(log, msg)
+			common.LogError.Error(msg)
+			// PASS
+		}
+	}
+
+	if ref.IsBranch() {
+		err = ref.UpdateState(client, checkName, "success", targetURL, "total: "+util.FormatBytes(total))
+		if err != nil {
+			msg := fmt.Sprintf("Update commit state %s failed: %v", checkName, err)
+			_, _ = io.WriteString(log, msg+"\n")
+			common.LogError.Error(msg)
+			// PASS
+		}
+		return 0, "", nil
+	}
+
+	baseSHA, err := util.GetBaseSHA(ctx, client, ref.Owner, ref.RepoName, gpull.GetNumber())
+	if err != nil {
+		err = fmt.Errorf("cannot get BaseSHA: %v", err)
+		reportError(err)
+		return 0, "", err
+	}
+	baseSizes, err := store.ListCommitsSizes(ref.Owner, ref.RepoName, baseSHA)
+	if err != nil {
+		err = fmt.Errorf("failed to load base sizes: %v", err)
+		reportError(err)
+		return 0, "", err
+	}
+	base := make(map[string]int64, len(baseSizes))
+	for _, s := range baseSizes {
+		base[s.Name] = s.Size
+	}
+	table, exceeded, err := util.DiffSizes(config, sizes, base)
+	if err != nil {
+		reportError(err)
+		return 0, "", err
+	}
+	_, _ = io.WriteString(log, table+"\n")
+
+	conclusion := "success"
+	title := "total: " + util.FormatBytes(total)
+	message := table
+	if len(base) == 0 {
+		message += "\nNo sizes of base " + baseSHA + " were recorded.\n"
+	}
+	if len(exceeded) > 0 {
+		conclusion = "failure"
+		title = fmt.Sprintf("%d output(s) grow more than %s", len(exceeded), config.MaxGrowth)
+	}
+
+	checkRun, err := CreateCheckRun(ctx, client, gpull, checkName, ref, targetURL)
+	if err != nil {
+		msg := fmt.Sprintf("Creating %s check run failed: %v", checkName, err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		return len(exceeded), table, err
+	}
+	t := github.Timestamp{Time: time.Now()}
+	err = UpdateCheckRun(ctx, client, gpull, checkRun.GetID(), checkName, conclusion, t, title, message, nil)
+	if err != nil {
+		msg := fmt.Sprintf("report sizes to github failed: %v", err)
+		_, _ = io.WriteString(log, msg+"\n")
+		common.LogError.Error(msg)
+		// PASS
 	}
-	return len(data), nil
+	return len(exceeded), table, nil
 }
@@ -1,10 +1,16 @@
 package checker
 
 import (
+	"context"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tengattack/unified-ci/common"
 	"github.com/tengattack/unified-ci/config"
 	"github.com/tengattack/unified-ci/store"
@@ -35,3 +41,44 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+func TestCheckCommitVulnerability(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defer func(conf config.SectionVulnerability, git string) {
+		common.Conf.Vulnerability, common.Conf.Core.GitCommand = conf, git
+	}(common.Conf.Vulnerability, common.Conf.Core.GitCommand)
+	osvPath, err := filepath.Abs("../checks/vulnerability/testdata/osv")
+	require.NoError(err)
+	common.Conf.Vulnerability = config.SectionVulnerability{Provider: "osv", OSVPath: osvPath}
+	common.Conf.Core.GitCommand = "git"
+
+	repoPath, err := ioutil.TempDir("", "unified-ci-test-")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+	for _, name := range []string{"package.json", "package-lock.json"} {
+		content, err := ioutil.ReadFile(filepath.Join("../checks/vulnerability/testdata/packages/npm", name))
+		require.NoError(err)
+		require.NoError(ioutil.WriteFile(filepath.Join(repoPath, name), content, 0644))
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.CombinedOutput()
+		require.NoError(err, string(out))
+	}
+	// the lockfile is rewritten in the checkout after the commit
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "package-lock.json"), []byte("{}"), 0644))
+
+	ref := common.GithubRef{Owner: "owner", RepoName: "repo"}
+	data, err := checkCommitVulnerability(context.Background(), ref, repoPath, "HEAD")
+	require.NoError(err)
+	require.Len(data, 1)
+	assert.Equal("lodash", data[0].Name)
+	assert.Equal("4.17.15", data[0].Version)
+}
@@ -1,11 +1,13 @@
 package checker
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -13,8 +15,10 @@ import (
 	"github.com/google/go-github/github"
 	"github.com/sourcegraph/go-diff/diff"
 	"github.com/tengattack/unified-ci/checks/lint"
+	"github.com/tengattack/unified-ci/checks/outdated"
 	"github.com/tengattack/unified-ci/checks/tester"
 	"github.com/tengattack/unified-ci/common"
+	"github.com/tengattack/unified-ci/store"
 	"github.com/tengattack/unified-ci/util"
 	"golang.org/x/sync/errgroup"
 )
@@ -91,15 +95,18 @@ func HandleMessage(ctx context.Context, message string) error {
 	if m.CheckType == "tree" {
 		ref.CheckType = common.CheckTypeBranch
 		ref.CheckRef = m.Branch
+		ref.HeadBranch = m.Branch
 	} else {
 		ref.CheckType = common.CheckTypePRHead
 		ref.CheckRef = fmt.Sprintf("pr/%d", m.PRNum)
+		ref.PRNum = m.PRNum
 	}
 
 	targetURL := ""
 	if len(common.Conf.Core.CheckLogURI) > 0 {
 		targetURL = common.Conf.Core.CheckLogURI + m.Repository() + "/" + ref.Sha + ".log"
 	}
+	ref.LogURL = targetURL
 
 	repoLogsPath := filepath.Join(common.Conf.Core.LogsDir, m.Repository())
 	_ = os.MkdirAll(repoLogsPath, os.ModePerm)
@@ -156,6 +163,9 @@ func HandleMessage(ctx context.Context, message string) error {
 			log.WriteString("PR " + gpull.GetState() + ".\n")
 			return nil
 		}
+		ref.BaseBranch = gpull.GetBase().GetRef()
+		ref.HeadBranch = gpull.GetHead().GetRef()
+		ref.Author = gpull.GetUser().GetLogin()
 	}
 
 	err = ref.UpdateState(client, common.AppName, "pending", targetURL, "checking")
@@ -287,6 +297,24 @@ func HandleMessage(ctx context.Context, message string) error {
 		return err
 	}
 
+	ref.Env = repoConf.Env
+	ref.Secrets = loadSecrets(ref, gpull, log)
+
+	cacheKey, cacheHit := restoreCache(ref, repoPath, repoConf.Cache, log)
+
+	// the vulnerability scanning mostly waits for the provider, so that it
+	// runs concurrently with the lints and tests
+	var (
+		vulnerabilitiesCount int
+		vulnerabilityLog     bytes.Buffer
+		vulnerabilityDone    = make(chan struct{})
+	)
+	go func() {
+		defer close(vulnerabilityDone)
+		vulnerabilitiesCount, _ = VulnerabilityCheckRun(ctx, client, gpull, ref, repoPath,
+			repoConf.Vulnerability, targetURL, &vulnerabilityLog)
+	}()
+
 	var (
 		failedLints int
 
@@ -300,12 +328,12 @@ func HandleMessage(ctx context.Context, message string) error {
 	if ref.IsBranch() {
 		// only tests
 		failedLints = 0
-		failedTests, passedTests, errTests, testMsg = checkTests(ctx, repoPath, repoConf.Tests, client, gpull, ref, targetURL, log)
+		failedTests, passedTests, errTests, testMsg = checkTests(ctx, repoPath, repoConf.Tests, repoConf.Cache, diffs, client, gpull, ref, targetURL, log)
 		if failedTests+passedTests+errTests > 0 {
 			noTest = false
 		}
 	} else if repoConf.LinterAfterTests {
-		failedTests, passedTests, errTests, testMsg = checkTests(ctx, repoPath, repoConf.Tests, client, gpull, ref, targetURL, log)
+		failedTests, passedTests, errTests, testMsg = checkTests(ctx, repoPath, repoConf.Tests, repoConf.Cache, diffs, client, gpull, ref, targetURL, log)
 		if failedTests+passedTests+errTests > 0 {
 			noTest = false
 		}
@@ -313,24 +341,69 @@ func HandleMessage(ctx context.Context, message string) error {
 		failedLints, err = checkLints(ctx, client, gpull, ref, targetURL,
 			repoPath, diffs, lintEnabled, repoConf.IgnorePatterns, log)
 		if err != nil {
+			<-vulnerabilityDone
+			_, _ = log.Write(vulnerabilityLog.Bytes())
 			return err
 		}
 	} else {
 		failedLints, err = checkLints(ctx, client, gpull, ref, targetURL,
 			repoPath, diffs, lintEnabled, repoConf.IgnorePatterns, log)
 		if err != nil {
+			<-vulnerabilityDone
+			_, _ = log.Write(vulnerabilityLog.Bytes())
 			return err
 		}
 
-		failedTests, passedTests, errTests, testMsg = checkTests(ctx, repoPath, repoConf.Tests, client, gpull, ref, targetURL, log)
+		failedTests, passedTests, errTests, testMsg = checkTests(ctx, repoPath, repoConf.Tests, repoConf.Cache, diffs, client, gpull, ref, targetURL, log)
 		if failedTests+passedTests+errTests > 0 {
 			noTest = false
 		}
 	}
-	vulnerabilitiesCount, _ := VulnerabilityCheckRun(ctx, client, gpull, ref, repoPath, targetURL, log)
+	if canSaveCache(ref, cacheKey, cacheHit, failedTests+errTests) {
+		saveCache(ref, repoPath, repoConf.Cache, cacheKey, log)
+	}
+	var (
+		sizeExceeded int
+		sizeTable    string
+	)
+	if repoConf.Size.Enabled() {
+		sizeExceeded, sizeTable, _ = SizeCheckRun(ctx, client, gpull, ref, repoPath, repoConf.Size, targetURL, log)
+	}
+	<-vulnerabilityDone
+	_, _ = log.Write(vulnerabilityLog.Bytes())
+	var benchmarkRegressions int
+	if !ref.IsBranch() && repoConf.Benchmarks.Enabled() {
+		benchmarkRegressions, _ = BenchmarkCheckRun(ctx, client, gpull, ref, repoPath,
+			repoConf.Benchmarks, repoConf.Cache, targetURL, log)
+	}
+	var licenseViolations int
+	if repoConf.License.Enabled() {
+		licenseViolations, _ = LicenseCheckRun(ctx, client, gpull, ref, repoPath, repoConf.License, targetURL, log)
+	}
+	var secretsFound int
+	if !ref.IsBranch() && !repoConf.SecretScan.Disabled {
+		secretsFound, _ = SecretScanCheckRun(ctx, client, gpull, ref, diffs, repoConf.SecretScan, targetURL, log)
+	}
+	var securityProblems int
+	if repoConf.Security.Enabled {
+		securityProblems, _ = SecurityCheckRun(ctx, client, gpull, ref, repoPath, diffs, repoConf.Security,
+			repoConf.IgnorePatterns, targetURL, log)
+	}
+	var outdatedTable string
+	if !ref.IsBranch() && repoConf.Outdated.Enabled() && outdated.Touched(util.ChangedFiles(diffs)) {
+		outdatedTable, _ = OutdatedCheckRun(ctx, client, gpull, ref, repoPath, repoConf.Outdated, targetURL, log)
+	}
+	if ref.IsBranch() {
+		err = GenerateSBOM(ref, repoPath, log)
+		if err != nil {
+			log.WriteString("Generate SBOM error: " + err.Error() + "\n")
+			common.LogError.Errorf("Generate SBOM error: %v", err)
+			// PASS
+		}
+	}
 
 	mark := '✔'
-	sumCount := failedLints + failedTests + vulnerabilitiesCount
+	sumCount := failedLints + failedTests + sizeExceeded + benchmarkRegressions + vulnerabilitiesCount + licenseViolations + secretsFound + securityProblems
 	if sumCount > 0 {
 		mark = '✖'
 	}
@@ -360,16 +433,43 @@ func HandleMessage(ctx context.Context, message string) error {
 		if sumCount > 0 {
 			comment := fmt.Sprintf("**lint**: %d problem(s) found.\n", failedLints)
 			comment += fmt.Sprintf("**vulnerability**: %d problem(s) found.\n", vulnerabilitiesCount)
+			if secretsFound > 0 {
+				comment += fmt.Sprintf("**secrets**: %d problem(s) found.\n", secretsFound)
+			}
+			if securityProblems > 0 {
+				comment += fmt.Sprintf("**security**: %d problem(s) found.\n", securityProblems)
+			}
+			if licenseViolations > 0 {
+				comment += fmt.Sprintf("**license**: %d problem(s) found.\n", licenseViolations)
+			}
+			if benchmarkRegressions > 0 {
+				comment += fmt.Sprintf("**benchmark**: %d regression(s) found.\n", benchmarkRegressions)
+			}
+			if sizeExceeded > 0 {
+				comment += fmt.Sprintf("**size**: %d problem(s) found.\n", sizeExceeded)
+			}
 			if !noTest {
 				comment += fmt.Sprintf("**test**: %d problem(s) found.\n\n", failedTests)
 				comment += testMsg
 			}
+			if sizeTable != "" {
+				comment += "\n**size**:\n\n" + sizeTable
+			}
+			if outdatedTable != "" {
+				comment += "\n**outdated dependencies**:\n\n" + outdatedTable
+			}
 			err = ref.CreateReview(client, m.PRNum, "REQUEST_CHANGES", comment, nil)
 		} else {
 			comment := "**check**: no problems found.\n"
 			if !noTest {
 				comment += ("\n" + testMsg)
 			}
+			if sizeTable != "" {
+				comment += "\n**size**:\n\n" + sizeTable
+			}
+			if outdatedTable != "" {
+				comment += "\n**outdated dependencies**:\n\n" + outdatedTable
+			}
 			err = ref.CreateReview(client, m.PRNum, "APPROVE", comment, nil)
 		}
 		if err != nil {
@@ -438,10 +538,121 @@ func filterLints(ignoredPath []string, annotations []*github.CheckRunAnnotation)
 	return filteredAnnotations, len(annotations) - len(filteredAnnotations)
 }
 
-func checkTests(ctx context.Context, repoPath string, tests map[string]util.TestsConfig,
+// restoreCache restores the dependency cache of the repo, it returns the cache key
+// and whether it hit
+func restoreCache(ref common.GithubRef, repoPath string, cache util.CacheConfig, log *os.File) (string, bool) {
+	if !cache.Enabled() {
+		return "", false
+	}
+	key, err := cache.Key(repoPath)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get cache key: %v\n", err)
+		common.LogError.Error(msg)
+		log.WriteString(msg)
+		return "", false
+	}
+	hit, err := util.RestoreCache(common.Conf.Core.CacheDir, ref.Owner, ref.RepoName, key, cache, repoPath)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to restore cache %s: %v\n", key, err)
+		common.LogError.Error(msg)
+		log.WriteString(msg)
+		// PASS: the cache will be saved again
+		return key, false
+	}
+	if hit {
+		log.WriteString("Cache hit: " + key + "\n\n")
+	} else {
+		log.WriteString("Cache miss: " + key + "\n\n")
+	}
+	return key, hit
+}
+
+// isForkPull checks if the head of the pull request is from another repo
+func isForkPull(gpull *github.PullRequest) bool {
+	head := gpull.GetHead().GetRepo().GetFullName()
+	return head == "" || head != gpull.GetBase().GetRepo().GetFullName()
+}
+
+// loadSecrets decrypts the secrets of the repo for the tests, the secrets are
+// withheld from the pull requests from forks.
+func loadSecrets(ref common.GithubRef, gpull *github.PullRequest, log *os.File) map[string]string {
+	if common.Conf.Secrets.Key == "" {
+		return nil
+	}
+	secrets, err := store.ListRepoSecrets(ref.Owner, ref.RepoName)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to list secrets: %v\n", err)
+		common.LogError.Error(msg)
+		log.WriteString(msg)
+		return nil
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	if !ref.IsBranch() && isForkPull(gpull) {
+		log.WriteString("Secrets are not provided to pull requests from forks\n\n")
+		return nil
+	}
+	key, err := util.ParseSecretsKey(common.Conf.Secrets.Key)
+	if err != nil {
+		common.LogError.Errorf("Failed to load secrets: %v", err)
+		log.WriteString("Failed to load secrets\n\n")
+		return nil
+	}
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		value, err := util.DecryptSecret(key, secret.Value)
+		if err != nil {
+			common.LogError.Errorf("Failed to decrypt secret %s of %s/%s: %v", secret.Name, ref.Owner, ref.RepoName, err)
+			log.WriteString("Failed to decrypt secret " + secret.Name + "\n")
+			// PASS
+			continue
+		}
+		values[secret.Name] = value
+	}
+	return values
+}
+
+// canSaveCache checks if the dependency cache of the passed tests can be
+// saved. Only the branch pushes save it, the scripts of the pull requests may
+// write anything into the cached directories restored by the later runs.
+func canSaveCache(ref common.GithubRef, key string, hit bool, failed int) bool {
+	return ref.IsBranch() && key != "" && !hit && failed == 0
+}
+
+// saveCache saves the dependency cache of the repo
+func saveCache(ref common.GithubRef, repoPath string, cache util.CacheConfig, key string, log *os.File) {
+	err := util.SaveCache(common.Conf.Core.CacheDir, ref.Owner, ref.RepoName, key, cache, repoPath)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to save cache %s: %v\n", key, err)
+		common.LogError.Error(msg)
+		log.WriteString(msg)
+		// PASS
+		return
+	}
+	log.WriteString("Cache saved: " + key + "\n\n")
+}
+
+func checkTests(ctx context.Context, repoPath string, tests map[string]util.TestsConfig, cache util.CacheConfig, diffs []*diff.FileDiff,
 	client *github.Client, gpull *github.PullRequest, ref common.GithubRef,
 	targetURL string, log *os.File) (failedTests, passedTests, errTests int, testMsg string) {
 
+	if !ref.IsBranch() {
+		// skip the tests whose paths are not changed in the pull request
+		var skipped map[string]string
+		tests, skipped = util.FilterTests(tests, util.ChangedFiles(diffs))
+		skippedNames := make([]string, 0, len(skipped))
+		for testName := range skipped {
+			skippedNames = append(skippedNames, testName)
+		}
+		sort.Strings(skippedNames)
+		for _, testName := range skippedNames {
+			reason := "skipped: " + skipped[testName]
+			log.WriteString(fmt.Sprintf("Testing '%s'\n%s\n\n", testName, reason))
+			reportSkippedTest(ctx, client, gpull, ref, targetURL, testName+" test", reason+"\n", log)
+		}
+	}
+
 	var baseSHA string
 	if !ref.IsBranch() {
 		// compare test coverage with base
@@ -455,15 +666,28 @@ func checkTests(ctx context.Context, repoPath string, tests map[string]util.Test
 		}
 		ref.BaseSha = baseSHA
 	}
+	// the base is tested in its own worktree concurrently with the head
+	var (
+		wg           sync.WaitGroup
+		baseCoverage sync.Map
+		baseLog      bytes.Buffer
+	)
+	if !ref.IsBranch() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			baseSavedRecords, baseTestsNeedToRun := tester.LoadBaseFromStore(ref, baseSHA, tests, &baseLog)
+			_ = tester.FindBaseCoverage(ctx, baseSavedRecords, baseTestsNeedToRun, repoPath, baseSHA, gpull, ref, cache, &baseLog, &baseCoverage)
+		}()
+	}
 	var headCoverage sync.Map
 	failedTests, passedTests, errTests, _ = TestCheckRun(ctx,
 		repoPath, client, gpull, ref,
 		targetURL, tests, &headCoverage, log)
+	wg.Wait()
 
 	if !ref.IsBranch() {
-		baseSavedRecords, baseTestsNeedToRun := tester.LoadBaseFromStore(ref, baseSHA, tests, log)
-		var baseCoverage sync.Map
-		_ = tester.FindBaseCoverage(ctx, baseSavedRecords, baseTestsNeedToRun, repoPath, baseSHA, gpull, ref, log, &baseCoverage)
+		_, _ = log.Write(baseLog.Bytes())
 		testMsg = util.DiffCoverage(&headCoverage, &baseCoverage)
 	}
 	return
@@ -38,3 +38,36 @@ func TestFilterLints(t *testing.T) {
 	assert.Empty(annotations)
 	assert.Equal(1, filtered)
 }
+
+func TestCanSaveCache(t *testing.T) {
+	assert := assert.New(t)
+
+	branch := common.GithubRef{CheckType: common.CheckTypeBranch}
+	pull := common.GithubRef{CheckType: common.CheckTypePRHead}
+	assert.True(canSaveCache(branch, "key", false, 0))
+	assert.False(canSaveCache(branch, "", false, 0))
+	assert.False(canSaveCache(branch, "key", true, 0))
+	assert.False(canSaveCache(branch, "key", false, 1))
+	// the pull requests never save the cache
+	assert.False(canSaveCache(pull, "key", false, 0))
+}
+
+func TestIsForkPull(t *testing.T) {
+	assert := assert.New(t)
+
+	repo := &github.Repository{FullName: github.String("owner/repo")}
+	fork := &github.Repository{FullName: github.String("someone/repo")}
+	assert.False(isForkPull(&github.PullRequest{
+		Base: &github.PullRequestBranch{Repo: repo},
+		Head: &github.PullRequestBranch{Repo: repo},
+	}))
+	assert.True(isForkPull(&github.PullRequest{
+		Base: &github.PullRequestBranch{Repo: repo},
+		Head: &github.PullRequestBranch{Repo: fork},
+	}))
+	// the head repo is deleted
+	assert.True(isForkPull(&github.PullRequest{
+		Base: &github.PullRequestBranch{Repo: repo},
+		Head: &github.PullRequestBranch{},
+	}))
+}
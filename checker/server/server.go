@@ -68,6 +68,11 @@ func routerEngine(mode worker.Mode) *gin.Engine {
 		r.Any("/api/queue/status/:action", showQueueStatusHandler)
 		r.POST(common.Conf.API.WebHookURI, webhookHandler)
 		r.GET("/badges/:owner/:repo/:type", worker.BadgesHandler)
+		r.GET("/artifacts/:owner/:repo/:sha/:name", worker.ArtifactsHandler)
+		r.GET("/sbom/:owner/:repo/:sha", worker.SBOMHandler)
+		r.GET("/api/secrets/:owner/:repo", worker.ListSecretsHandler)
+		r.PUT("/api/secrets/:owner/:repo/:name", worker.PutSecretHandler)
+		r.DELETE("/api/secrets/:owner/:repo/:name", worker.DeleteSecretHandler)
 	case worker.ModeServer:
 		r.POST("/api/queue/add", addQueueHandler)
 		r.Any("/api/queue/status", showQueueStatusHandler)
@@ -77,8 +82,18 @@ func routerEngine(mode worker.Mode) *gin.Engine {
 		r.POST("/api/worker/jobdone", worker.JobDoneHandler)
 		r.POST(common.Conf.API.WebHookURI, webhookHandler)
 		r.GET("/badges/:owner/:repo/:type", worker.ServerBadgesHandler)
+		r.GET("/artifacts/:owner/:repo/:sha/:name", worker.ServerArtifactsHandler)
+		r.GET("/sbom/:owner/:repo/:sha", worker.ServerSBOMHandler)
+		r.GET("/api/secrets/:owner/:repo", worker.ServerSecretsHandler)
+		r.PUT("/api/secrets/:owner/:repo/:name", worker.ServerSecretsHandler)
+		r.DELETE("/api/secrets/:owner/:repo/:name", worker.ServerSecretsHandler)
 	case worker.ModeWorker:
 		r.GET("/badges/:owner/:repo/:type", worker.BadgesHandler)
+		r.GET("/artifacts/:owner/:repo/:sha/:name", worker.ArtifactsHandler)
+		r.GET("/sbom/:owner/:repo/:sha", worker.SBOMHandler)
+		r.GET("/api/secrets/:owner/:repo", worker.ListSecretsHandler)
+		r.PUT("/api/secrets/:owner/:repo/:name", worker.PutSecretHandler)
+		r.DELETE("/api/secrets/:owner/:repo/:name", worker.DeleteSecretHandler)
 	}
 	r.GET("/version", versionHandler)
 	r.GET("/", rootHandler)
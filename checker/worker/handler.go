@@ -2,23 +2,35 @@ package worker
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
-	"io/ioutil"
 	"math"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tengattack/unified-ci/checks/sbom"
 	"github.com/tengattack/unified-ci/common"
 	"github.com/tengattack/unified-ci/log"
 	"github.com/tengattack/unified-ci/store"
+	"github.com/tengattack/unified-ci/util"
 )
 
 var httpClient = &http.Client{Timeout: 2 * time.Second}
 
+// artifactsHTTPClient has no total timeout as the artifacts may be large
+var artifactsHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 2 * time.Second}).DialContext,
+		ResponseHeaderTimeout: 10 * time.Second,
+	},
+}
+
 // TODO: migrate with server
 func abortWithError(c *gin.Context, code int, message string) {
 	c.AbortWithStatusJSON(code, gin.H{
@@ -29,6 +41,21 @@ func abortWithError(c *gin.Context, code int, message string) {
 
 // ServerBadgesHandler get project badge route by server worker
 func ServerBadgesHandler(c *gin.Context) {
+	proxyToProjectWorker(c, httpClient)
+}
+
+// ServerArtifactsHandler get test artifacts route by server worker
+func ServerArtifactsHandler(c *gin.Context) {
+	proxyToProjectWorker(c, artifactsHTTPClient)
+}
+
+// ServerSecretsHandler manages the secrets of the project on its worker
+func ServerSecretsHandler(c *gin.Context) {
+	proxyToProjectWorker(c, httpClient)
+}
+
+// proxyToProjectWorker forwards the request to the worker of the project
+func proxyToProjectWorker(c *gin.Context, client *http.Client) {
 	owner := c.Param("owner")
 	repo := c.Param("repo")
 
@@ -59,23 +86,27 @@ func ServerBadgesHandler(c *gin.Context) {
 		return
 	}
 
-	req, err := http.NewRequest(http.MethodGet, "http://"+w.Addr+c.Request.RequestURI, nil)
+	req, err := http.NewRequest(c.Request.Method, "http://"+w.Addr+c.Request.RequestURI, c.Request.Body)
 	if err != nil {
 		abortWithError(c, 500, fmt.Sprintf("new request error: %v", err))
 		return
 	}
-	resp, err := httpClient.Do(req)
+	for _, h := range []string{"Authorization", "Content-Type"} {
+		if v := c.GetHeader(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		abortWithError(c, 500, fmt.Sprintf("http client do error: %v", err))
 		return
 	}
-	body, err := ioutil.ReadAll(resp.Body)
 	defer resp.Body.Close()
-	if err != nil {
-		abortWithError(c, 500, fmt.Sprintf("read resp body error: %v", err))
-		return
+	extraHeaders := make(map[string]string)
+	if v := resp.Header.Get("Content-Disposition"); v != "" {
+		extraHeaders["Content-Disposition"] = v
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, extraHeaders)
 }
 
 // BadgesHandler get project badge
@@ -314,3 +345,167 @@ func JobDoneHandler(c *gin.Context) {
 		"info": "success",
 	})
 }
+
+// ArtifactsHandler downloads the test artifacts
+func ArtifactsHandler(c *gin.Context) {
+	path, ok := artifactPath(c, c.Param("name"))
+	if !ok {
+		return
+	}
+	c.FileAttachment(path, c.Param("name"))
+}
+
+// ServerSBOMHandler get sbom route by server worker
+func ServerSBOMHandler(c *gin.Context) {
+	proxyToProjectWorker(c, artifactsHTTPClient)
+}
+
+// SBOMHandler gets the SBOM of the commit in the format of the query, which
+// is cyclonedx by default
+func SBOMHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", sbom.CycloneDX)
+	name, err := sbom.FileName(format)
+	if err != nil {
+		abortWithError(c, 400, err.Error())
+		return
+	}
+	path, ok := artifactPath(c, name)
+	if !ok {
+		return
+	}
+	c.File(path)
+}
+
+// artifactPath returns the path of the artifact of the commit in params, it
+// aborts if the artifact is not found
+func artifactPath(c *gin.Context, name string) (string, bool) {
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+	sha := c.Param("sha")
+
+	for _, p := range []string{owner, repo, sha, name} {
+		if p == "" || p == "." || p == ".." || strings.ContainsAny(p, "/\\") {
+			abortWithError(c, 400, "params error")
+			return "", false
+		}
+	}
+
+	path := filepath.Join(util.ArtifactsDir(common.Conf.Core.LogsDir, owner, repo, sha), name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		abortWithError(c, 404, "artifacts not found")
+		return "", false
+	}
+	if util.ArtifactsExpired(info.ModTime(), common.Conf.Artifacts.Retention) {
+		abortWithError(c, 404, "artifacts expired")
+		return "", false
+	}
+	return path, true
+}
+
+// secretsAuthorized checks the bearer token of the secrets API
+func secretsAuthorized(c *gin.Context) bool {
+	token := common.Conf.Secrets.Token
+	if token == "" {
+		abortWithError(c, 403, "secrets api is disabled")
+		return false
+	}
+	auth := c.GetHeader("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") ||
+		subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+		abortWithError(c, 401, "unauthorized")
+		return false
+	}
+	return true
+}
+
+// ListSecretsHandler lists the names of the secrets of the repo
+func ListSecretsHandler(c *gin.Context) {
+	if !secretsAuthorized(c) {
+		return
+	}
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+
+	secrets, err := store.ListRepoSecrets(owner, repo)
+	if err != nil {
+		abortWithError(c, 500, "list secrets error: "+err.Error())
+		return
+	}
+	list := make([]gin.H, 0, len(secrets))
+	for _, secret := range secrets {
+		list = append(list, gin.H{
+			"name":        secret.Name,
+			"update_time": secret.UpdateTime,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"info": list,
+	})
+}
+
+// PutSecretHandler creates or updates a secret of the repo
+func PutSecretHandler(c *gin.Context) {
+	if !secretsAuthorized(c) {
+		return
+	}
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+	name := c.Param("name")
+	value := c.PostForm("value")
+
+	if !util.ValidSecretName(name) || value == "" {
+		abortWithError(c, 400, "params error")
+		return
+	}
+	key, err := util.ParseSecretsKey(common.Conf.Secrets.Key)
+	if err != nil {
+		abortWithError(c, 500, err.Error())
+		return
+	}
+	encrypted, err := util.EncryptSecret(key, value)
+	if err != nil {
+		abortWithError(c, 500, "encrypt secret error: "+err.Error())
+		return
+	}
+	secret := store.RepoSecret{
+		Owner: owner,
+		Repo:  repo,
+		Name:  name,
+		Value: encrypted,
+	}
+	err = secret.Save()
+	if err != nil {
+		abortWithError(c, 500, "save secret error: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"info": "success",
+	})
+}
+
+// DeleteSecretHandler deletes a secret of the repo
+func DeleteSecretHandler(c *gin.Context) {
+	if !secretsAuthorized(c) {
+		return
+	}
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+	name := c.Param("name")
+
+	deleted, err := store.DeleteRepoSecret(owner, repo, name)
+	if err != nil {
+		abortWithError(c, 500, "delete secret error: "+err.Error())
+		return
+	}
+	if !deleted {
+		abortWithError(c, 404, "secret not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"info": "success",
+	})
+}
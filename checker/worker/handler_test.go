@@ -1,14 +1,24 @@
 package worker
 
 import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/common"
 	"github.com/tengattack/unified-ci/store"
+	"github.com/tengattack/unified-ci/util"
 )
 
 func TestBadgesHandler(t *testing.T) {
@@ -81,3 +91,140 @@ func TestBadgesHandler(t *testing.T) {
 	assert.Contains(resp.Body.String(), ">build<")
 	assert.Contains(resp.Body.String(), ">passing<") // round to integer
 }
+
+func TestArtifactsHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	logsDir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(logsDir)
+	common.Conf.Core.LogsDir = logsDir
+	common.Conf.Artifacts.Retention = time.Hour
+
+	dir := util.ArtifactsDir(logsDir, "owner", "repo", "sha")
+	require.NoError(os.MkdirAll(dir, 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "unit.zip"), []byte("zip"), 0644))
+
+	resp := httptest.NewRecorder()
+	c, r := gin.CreateTestContext(resp)
+	r.GET("/artifacts/:owner/:repo/:sha/:name", ArtifactsHandler)
+
+	c.Request = httptest.NewRequest(http.MethodGet, "/artifacts/owner/repo/sha/unit.zip", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("zip", resp.Body.String())
+	assert.Contains(resp.Header().Get("Content-Disposition"), "unit.zip")
+
+	resp = httptest.NewRecorder()
+	c.Request = httptest.NewRequest(http.MethodGet, "/artifacts/owner/repo/sha/..", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.NotEqual(http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	c.Request = httptest.NewRequest(http.MethodGet, "/artifacts/owner/repo/sha/none.zip", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.Equal(http.StatusNotFound, resp.Code)
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(os.Chtimes(filepath.Join(dir, "unit.zip"), old, old))
+	resp = httptest.NewRecorder()
+	c.Request = httptest.NewRequest(http.MethodGet, "/artifacts/owner/repo/sha/unit.zip", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.Equal(http.StatusNotFound, resp.Code)
+}
+
+func TestSBOMHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	logsDir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(logsDir)
+	common.Conf.Core.LogsDir = logsDir
+	common.Conf.Artifacts.Retention = time.Hour
+
+	dir := util.ArtifactsDir(logsDir, "owner", "repo", "sha")
+	require.NoError(os.MkdirAll(dir, 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "sbom.cdx.json"), []byte(`{"bomFormat":"CycloneDX"}`), 0644))
+
+	resp := httptest.NewRecorder()
+	c, r := gin.CreateTestContext(resp)
+	r.GET("/sbom/:owner/:repo/:sha", SBOMHandler)
+
+	c.Request = httptest.NewRequest(http.MethodGet, "/sbom/owner/repo/sha", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(`{"bomFormat":"CycloneDX"}`, resp.Body.String())
+	assert.Contains(resp.Header().Get("Content-Type"), "application/json")
+
+	resp = httptest.NewRecorder()
+	c.Request = httptest.NewRequest(http.MethodGet, "/sbom/owner/repo/sha?format=spdx", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.Equal(http.StatusNotFound, resp.Code)
+
+	resp = httptest.NewRecorder()
+	c.Request = httptest.NewRequest(http.MethodGet, "/sbom/owner/repo/sha?format=swid", nil)
+	r.ServeHTTP(resp, c.Request)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+}
+
+func TestSecretsHandlers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	key := bytes.Repeat([]byte{1}, 32)
+	common.Conf.Secrets.Key = base64.StdEncoding.EncodeToString(key)
+	common.Conf.Secrets.Token = "token"
+	defer func() {
+		common.Conf.Secrets.Key = ""
+		common.Conf.Secrets.Token = ""
+	}()
+
+	_, r := gin.CreateTestContext(httptest.NewRecorder())
+	r.GET("/api/secrets/:owner/:repo", ListSecretsHandler)
+	r.PUT("/api/secrets/:owner/:repo/:name", PutSecretHandler)
+	r.DELETE("/api/secrets/:owner/:repo/:name", DeleteSecretHandler)
+
+	do := func(method, path, token string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(http.MethodPut, "/api/secrets/owner/repo/TOKEN", "", url.Values{"value": {"s3cr3t"}})
+	assert.Equal(http.StatusUnauthorized, resp.Code)
+	resp = do(http.MethodPut, "/api/secrets/owner/repo/TOKEN", "wrong", url.Values{"value": {"s3cr3t"}})
+	assert.Equal(http.StatusUnauthorized, resp.Code)
+	resp = do(http.MethodPut, "/api/secrets/owner/repo/BAD-NAME", "token", url.Values{"value": {"s3cr3t"}})
+	assert.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = do(http.MethodPut, "/api/secrets/owner/repo/TOKEN", "token", url.Values{"value": {"s3cr3t"}})
+	assert.Equal(http.StatusOK, resp.Code)
+
+	secrets, err := store.ListRepoSecrets("owner", "repo")
+	require.NoError(err)
+	require.Len(secrets, 1)
+	value, err := util.DecryptSecret(key, secrets[0].Value)
+	require.NoError(err)
+	assert.Equal("s3cr3t", value)
+
+	resp = do(http.MethodGet, "/api/secrets/owner/repo", "token", nil)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"name":"TOKEN"`)
+	assert.NotContains(resp.Body.String(), "s3cr3t")
+
+	resp = do(http.MethodDelete, "/api/secrets/owner/repo/TOKEN", "token", nil)
+	assert.Equal(http.StatusOK, resp.Code)
+	resp = do(http.MethodDelete, "/api/secrets/owner/repo/TOKEN", "token", nil)
+	assert.Equal(http.StatusNotFound, resp.Code)
+
+	common.Conf.Secrets.Token = ""
+	resp = do(http.MethodGet, "/api/secrets/owner/repo", "", nil)
+	assert.Equal(http.StatusForbidden, resp.Code)
+}
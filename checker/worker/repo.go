@@ -256,3 +256,22 @@ func WatchLocalRepo(ctx context.Context) error {
 	}
 	return err
 }
+
+// CleanExpiredArtifacts removes the artifacts out of retention periodically
+func CleanExpiredArtifacts(ctx context.Context) error {
+	for {
+		removed, err := util.CleanExpiredArtifacts(common.Conf.Core.LogsDir, common.Conf.Artifacts.Retention)
+		if err != nil {
+			common.LogError.Errorf("CleanExpiredArtifacts error: %v", err)
+			// PASS
+		} else if removed > 0 {
+			common.LogAccess.Infof("Removed %d expired artifacts directories", removed)
+		}
+		select {
+		case <-ctx.Done():
+			common.LogAccess.Warn("CleanExpiredArtifacts canceled.")
+			return nil
+		case <-time.After(time.Hour):
+		}
+	}
+}
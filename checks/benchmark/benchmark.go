@@ -0,0 +1,293 @@
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Alpha is the significance level of the comparison
+const Alpha = 0.05
+
+// Key identifies the measurements of a benchmark in a unit
+type Key struct {
+	// Pkg is the package path from the "pkg:" line, if any
+	Pkg  string
+	Name string
+	Unit string
+}
+
+// String returns the name of the benchmark with its package name
+func (k Key) String() string {
+	if k.Pkg == "" {
+		return k.Name
+	}
+	return path.Base(k.Pkg) + "." + k.Name
+}
+
+// Set is the benchmark measurements parsed from the output
+type Set map[Key][]float64
+
+// Parse parses the output in the Go benchmark format, e.g.
+// "BenchmarkDecode-8   	 1000	   1234 ns/op	  56 B/op	   2 allocs/op"
+func Parse(r io.Reader) (Set, error) {
+	set := make(Set)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	pkg := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg:") {
+			pkg = strings.TrimSpace(strings.TrimPrefix(line, "pkg:"))
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.ParseUint(fields[1], 10, 64); err != nil {
+			// not a result line
+			continue
+		}
+		name := strings.TrimPrefix(fields[0], "Benchmark")
+		if name == "" {
+			continue
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			key := Key{Pkg: pkg, Name: name, Unit: fields[i+1]}
+			set[key] = append(set[key], v)
+		}
+	}
+	return set, scanner.Err()
+}
+
+// Stats is the summary of the measurements with the outliers removed
+type Stats struct {
+	Mean float64
+	// Diff is the largest distance from the mean in percent
+	Diff   float64
+	Values []float64
+}
+
+// NewStats summaries the values, the values out of 1.5 IQR are removed as outliers
+func NewStats(values []float64) Stats {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+	lo, hi := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
+	var s Stats
+	for _, v := range sorted {
+		if v >= lo && v <= hi {
+			s.Values = append(s.Values, v)
+		}
+	}
+	if len(s.Values) == 0 {
+		return s
+	}
+	for _, v := range s.Values {
+		s.Mean += v
+	}
+	s.Mean /= float64(len(s.Values))
+	if s.Mean != 0 {
+		min, max := s.Values[0], s.Values[len(s.Values)-1]
+		s.Diff = 100 * math.Max(s.Mean-min, max-s.Mean) / math.Abs(s.Mean)
+	}
+	return s
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Format formats the stats like "1.23ms ±2%"
+func (s Stats) Format(unit string) string {
+	if len(s.Values) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s ±%.0f%%", formatValue(s.Mean, unit), s.Diff)
+}
+
+func formatValue(v float64, unit string) string {
+	if unit == "ns/op" {
+		switch {
+		case v >= 1e9:
+			return fmt.Sprintf("%.2fs", v/1e9)
+		case v >= 1e6:
+			return fmt.Sprintf("%.2fms", v/1e6)
+		case v >= 1e3:
+			return fmt.Sprintf("%.2fµs", v/1e3)
+		}
+		return fmt.Sprintf("%.2fns", v)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64) + " " + unit
+}
+
+// MannWhitneyU returns the two-sided p-value of the Mann-Whitney U test,
+// using the normal approximation with tie correction.
+func MannWhitneyU(x, y []float64) float64 {
+	n1, n2 := float64(len(x)), float64(len(y))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type value struct {
+		v     float64
+		first bool
+	}
+	all := make([]value, 0, len(x)+len(y))
+	for _, v := range x {
+		all = append(all, value{v, true})
+	}
+	for _, v := range y {
+		all = append(all, value{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	var r1, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		// average rank of the tied values
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				r1 += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	u := r1 - n1*(n1+1)/2
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	// continuity correction
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// Comparison is the comparison of a benchmark between base and head
+type Comparison struct {
+	Key
+	Base, Head Stats
+	// Delta is the change of the mean in percent
+	Delta float64
+	P     float64
+	// Regression is true if the change is significant and worse than the threshold
+	Regression bool
+}
+
+// Significant reports whether the change is statistically significant
+func (c Comparison) Significant() bool {
+	return c.P < Alpha
+}
+
+// higherIsBetter reports whether a larger value of the unit is better, e.g. MB/s
+func higherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
+
+// Compare compares the benchmarks measured in both base and head, threshold is
+// the percent of the change to be a regression.
+func Compare(base, head Set, threshold float64) []Comparison {
+	var result []Comparison
+	for key, headValues := range head {
+		baseValues, ok := base[key]
+		if !ok {
+			continue
+		}
+		c := Comparison{
+			Key:  key,
+			Base: NewStats(baseValues),
+			Head: NewStats(headValues),
+		}
+		if len(c.Base.Values) == 0 || len(c.Head.Values) == 0 {
+			continue
+		}
+		if c.Base.Mean != 0 {
+			c.Delta = 100 * (c.Head.Mean - c.Base.Mean) / math.Abs(c.Base.Mean)
+		}
+		c.P = MannWhitneyU(c.Base.Values, c.Head.Values)
+		worse := c.Delta
+		if higherIsBetter(key.Unit) {
+			worse = -worse
+		}
+		c.Regression = c.Significant() && worse > threshold
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Unit != result[j].Unit {
+			return unitOrder(result[i].Unit) < unitOrder(result[j].Unit)
+		}
+		return result[i].String() < result[j].String()
+	})
+	return result
+}
+
+func unitOrder(unit string) string {
+	switch unit {
+	case "ns/op":
+		return "0"
+	case "B/op":
+		return "1"
+	case "allocs/op":
+		return "2"
+	}
+	return "3" + unit
+}
+
+// Regressions returns the number of the regressions
+func Regressions(comparisons []Comparison) int {
+	n := 0
+	for _, c := range comparisons {
+		if c.Regression {
+			n++
+		}
+	}
+	return n
+}
+
+// MDTable returns the comparisons in a markdown table
+func MDTable(comparisons []Comparison) string {
+	var b strings.Builder
+	b.WriteString("| name | unit | base | head | delta |\n")
+	b.WriteString("| ---- | ---- | ---- | ---- | ----- |\n")
+	for _, c := range comparisons {
+		delta := "~"
+		if c.Significant() {
+			delta = fmt.Sprintf("%+.2f%%", c.Delta)
+		}
+		delta += fmt.Sprintf(" (p=%.3f n=%d+%d)", c.P, len(c.Base.Values), len(c.Head.Values))
+		if c.Regression {
+			delta = "**" + delta + "** ✖"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", c.Key, c.Unit,
+			c.Base.Format(c.Unit), c.Head.Format(c.Unit), delta))
+	}
+	return b.String()
+}
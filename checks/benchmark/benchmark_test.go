@@ -0,0 +1,98 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseOutput = `goos: linux
+goarch: amd64
+pkg: github.com/tengattack/unified-ci/util
+BenchmarkMatch-8   	 1000000	      1000 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      1010 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	       990 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      1005 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      5000 ns/op	      64 B/op	       2 allocs/op
+BenchmarkCopy-8    	     100	  10000000 ns/op	 100.00 MB/s
+BenchmarkCopy-8    	     100	  10100000 ns/op	  99.00 MB/s
+BenchmarkCopy-8    	     100	   9900000 ns/op	 101.00 MB/s
+BenchmarkCopy-8    	     100	  10000000 ns/op	 100.00 MB/s
+BenchmarkCopy-8    	     100	  10050000 ns/op	  99.50 MB/s
+PASS
+ok  	github.com/tengattack/unified-ci/util	10.000s
+`
+
+const headOutput = `pkg: github.com/tengattack/unified-ci/util
+BenchmarkMatch-8   	 1000000	      1300 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      1310 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      1290 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      1305 ns/op	      64 B/op	       2 allocs/op
+BenchmarkMatch-8   	 1000000	      1295 ns/op	      64 B/op	       2 allocs/op
+BenchmarkCopy-8    	     100	  10010000 ns/op	 100.00 MB/s
+BenchmarkCopy-8    	     100	   9990000 ns/op	 100.10 MB/s
+BenchmarkCopy-8    	     100	  10020000 ns/op	  99.80 MB/s
+BenchmarkCopy-8    	     100	  10000000 ns/op	 100.00 MB/s
+BenchmarkCopy-8    	     100	   9980000 ns/op	 100.20 MB/s
+BenchmarkNew-8     	     100	       100 ns/op
+`
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	set, err := Parse(strings.NewReader(baseOutput))
+	require.NoError(err)
+	assert.Len(set, 5)
+	key := Key{Pkg: "github.com/tengattack/unified-ci/util", Name: "Match-8", Unit: "ns/op"}
+	assert.Equal([]float64{1000, 1010, 990, 1005, 5000}, set[key])
+	assert.Equal("util.Match-8", key.String())
+	assert.Len(set[Key{Pkg: key.Pkg, Name: "Copy-8", Unit: "MB/s"}], 5)
+}
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewStats([]float64{1000, 1010, 990, 1005, 5000})
+	// 5000 is an outlier
+	assert.Len(s.Values, 4)
+	assert.InDelta(1001.25, s.Mean, 0.001)
+	assert.Equal("1.00µs ±1%", s.Format("ns/op"))
+	assert.Equal("64 B/op ±0%", NewStats([]float64{64, 64}).Format("B/op"))
+
+	assert.Less(MannWhitneyU([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}), Alpha)
+	assert.Greater(MannWhitneyU([]float64{1, 3, 5, 7, 9}, []float64{2, 4, 6, 8, 10}), Alpha)
+	assert.Equal(1.0, MannWhitneyU([]float64{1}, []float64{2}))
+	assert.Equal(1.0, MannWhitneyU([]float64{2, 2}, []float64{2, 2}))
+}
+
+func TestCompare(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	base, err := Parse(strings.NewReader(baseOutput))
+	require.NoError(err)
+	head, err := Parse(strings.NewReader(headOutput))
+	require.NoError(err)
+
+	comparisons := Compare(base, head, 10)
+	require.Len(comparisons, 5)
+	assert.Equal("Copy-8", comparisons[0].Name)
+	assert.Equal("ns/op", comparisons[0].Unit)
+	assert.False(comparisons[0].Significant())
+
+	match := comparisons[1]
+	assert.Equal("Match-8", match.Name)
+	assert.True(match.Significant())
+	assert.True(match.Regression)
+	assert.InDelta(30, match.Delta, 0.5)
+	assert.Equal(1, Regressions(comparisons))
+
+	assert.Equal(0, Regressions(Compare(base, head, 50)))
+
+	table := MDTable(comparisons)
+	assert.Contains(table, "| util.Match-8 | ns/op | 1.00µs ±1% | 1.30µs ±1% | **+29.84% (p=0.020 n=4+5)** ✖ |")
+	assert.Contains(table, "| util.Copy-8 | MB/s |")
+}
@@ -0,0 +1,197 @@
+package dependency
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ecosystems of the dependencies, named as the OSV ecosystems
+const (
+	Go        = "Go"
+	Npm       = "npm"
+	Packagist = "Packagist"
+	Maven     = "Maven"
+	PyPI      = "PyPI"
+	Cargo     = "crates.io"
+	RubyGems  = "RubyGems"
+)
+
+// Dependency is a resolved package the repo depends on
+type Dependency struct {
+	Ecosystem string
+	Name      string
+	Version   string
+	// Direct is true if the repo requires the package itself, not through
+	// the other dependencies
+	Direct bool
+}
+
+// Parser parses the dependencies listed in the file
+type Parser func(path string) ([]Dependency, error)
+
+// parsers maps the file names to their parsers
+var parsers = map[string]Parser{
+	"go.sum":            parseGoSum,
+	"package-lock.json": parsePackageLock,
+	"yarn.lock":         parseYarnLock,
+	"pnpm-lock.yaml":    parsePnpmLock,
+	"composer.lock":     parseComposerLock,
+	"pom.xml":           parsePom,
+	"requirements.txt":  parseRequirements,
+	"poetry.lock":       parsePoetryLock,
+	"Cargo.lock":        parseCargoLock,
+	"Gemfile.lock":      parseGemfileLock,
+}
+
+// nodeLockfiles are the lockfiles of package.json in the order of preference
+var nodeLockfiles = []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml"}
+
+// companions are the files next to the dependency file which are read as well
+var companions = map[string][]string{
+	"go.sum":            {"go.mod"},
+	"package.json":      nodeLockfiles,
+	"package-lock.json": {"package.json"},
+	"yarn.lock":         {"package.json"},
+	"pnpm-lock.yaml":    {"package.json"},
+	"composer.lock":     {"composer.json"},
+	"poetry.lock":       {"pyproject.toml"},
+}
+
+// ReadFiles returns the names of the files in the same directory read by Parse
+// for the dependency file name, the name itself first
+func ReadFiles(name string) []string {
+	return append([]string{name}, companions[name]...)
+}
+
+// Supported reports whether the file can be parsed
+func Supported(path string) bool {
+	name := filepath.Base(path)
+	_, ok := parsers[name]
+	return ok || name == "package.json"
+}
+
+// Parse parses the dependencies in the file, which is a lockfile or a manifest
+// with resolved versions, package.json is parsed by its lockfile if any.
+func Parse(path string) ([]Dependency, error) {
+	name := filepath.Base(path)
+	if name == "package.json" {
+		for _, lockfile := range nodeLockfiles {
+			lockPath := filepath.Join(filepath.Dir(path), lockfile)
+			if _, err := os.Stat(lockPath); err == nil {
+				return Parse(lockPath)
+			}
+		}
+		return nil, nil
+	}
+	parse, ok := parsers[name]
+	if !ok {
+		return nil, nil
+	}
+	deps, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(deps), nil
+}
+
+// skipDirs are not searched for the dependency files
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"testdata":     true,
+}
+
+// Files returns the dependency files in the repo, the installed packages and
+// the hidden directories are skipped.
+func Files(repoPath string) ([]string, error) {
+	return findFiles(repoPath, func(name string) bool {
+		_, ok := parsers[name]
+		return ok
+	})
+}
+
+// findFiles returns the files in the repo whose names are matched, the
+// installed packages and the hidden directories are skipped.
+func findFiles(repoPath string, match func(name string) bool) ([]string, error) {
+	var files []string
+	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != repoPath && (skipDirs[name] || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(name) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Inventory parses all the dependencies of the repo
+func Inventory(repoPath string) ([]Dependency, error) {
+	files, err := Files(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var deps []Dependency
+	for _, file := range files {
+		d, err := Parse(file)
+		if err != nil {
+			rel, _ := filepath.Rel(repoPath, file)
+			return nil, &ParseError{File: filepath.ToSlash(rel), Err: err}
+		}
+		deps = append(deps, d...)
+	}
+	return Merge(deps), nil
+}
+
+// ParseError is the error parsing a dependency file
+type ParseError struct {
+	File string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return "failed to parse " + e.File + ": " + e.Err.Error()
+}
+
+// Merge removes the duplicated dependencies, a dependency is direct if any of
+// the duplicates is, the result is sorted.
+func Merge(deps []Dependency) []Dependency {
+	type key struct {
+		Ecosystem, Name, Version string
+	}
+	index := make(map[key]int, len(deps))
+	merged := make([]Dependency, 0, len(deps))
+	for _, d := range deps {
+		if d.Name == "" || d.Version == "" {
+			continue
+		}
+		k := key{d.Ecosystem, d.Name, d.Version}
+		if i, ok := index[k]; ok {
+			merged[i].Direct = merged[i].Direct || d.Direct
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, d)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.Ecosystem != b.Ecosystem {
+			return a.Ecosystem < b.Ecosystem
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return merged
+}
@@ -0,0 +1,138 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	for file, expected := range map[string][]Dependency{
+		"go/go.sum": {
+			{Go, "github.com/gin-gonic/gin", "v1.5.0", true},
+			{Go, "github.com/stretchr/testify", "v1.4.0", true},
+			{Go, "golang.org/x/net", "v0.0.0-20220906165146-f3363e06e74c", false},
+			{Go, "golang.org/x/text", "v0.3.2", false},
+		},
+		"npm/package.json": {
+			{Npm, "@types/node", "14.0.0", false},
+			{Npm, "debug", "2.6.9", false},
+			{Npm, "debug", "4.1.1", false},
+			{Npm, "express", "4.17.1", true},
+			{Npm, "mocha", "8.0.1", true},
+		},
+		"yarn/package.json": {
+			{Npm, "@babel/core", "7.12.3", true},
+			{Npm, "debug", "4.3.1", false},
+			{Npm, "lodash", "4.17.15", true},
+		},
+		"yarn-berry/yarn.lock": {
+			{Npm, "lodash", "4.17.21", true},
+			{Npm, "ms", "2.1.2", false},
+		},
+		"pnpm/pnpm-lock.yaml": {
+			{Npm, "js-tokens", "4.0.0", false},
+			{Npm, "loose-envify", "1.4.0", false},
+			{Npm, "object-assign", "4.1.1", false},
+			{Npm, "react", "16.14.0", false},
+			{Npm, "react", "17.0.2", true},
+		},
+		"php/composer.lock": {
+			{Packagist, "monolog/monolog", "1.25.1", true},
+			{Packagist, "phpunit/phpunit", "8.5.8", true},
+			{Packagist, "psr/log", "1.1.3", false},
+		},
+		"java/pom.xml": {
+			{Maven, "com.example:common", "1.2.0", true},
+			{Maven, "com.fasterxml.jackson.core:jackson-databind", "2.9.10.1", true},
+			{Maven, "org.apache.logging.log4j:log4j-core", "2.14.1", true},
+		},
+		"python/requirements.txt": {
+			{PyPI, "django", "2.2.10", true},
+			{PyPI, "pyyaml", "5.1", true},
+			{PyPI, "requests", "2.22.0", true},
+		},
+		"poetry/poetry.lock": {
+			{PyPI, "click", "7.1.2", false},
+			{PyPI, "flask", "1.1.2", true},
+			{PyPI, "pytest", "6.1.1", true},
+		},
+		"rust/Cargo.lock": {
+			{Cargo, "libc", "0.2.139", false},
+			{Cargo, "rand", "0.7.3", false},
+			{Cargo, "rand", "0.8.5", true},
+			{Cargo, "serde", "1.0.152", true},
+		},
+		"ruby/Gemfile.lock": {
+			{RubyGems, "nokogiri", "1.13.10", true},
+			{RubyGems, "racc", "1.6.2", false},
+			{RubyGems, "rack", "2.2.3", true},
+		},
+	} {
+		deps, err := Parse("testdata/" + file)
+		require.NoError(err, file)
+		assert.Equal(expected, deps, file)
+	}
+
+	deps, err := Parse("testdata/go/go.mod")
+	assert.NoError(err)
+	assert.Empty(deps)
+	_, err = Parse("testdata/php/missing/composer.lock")
+	assert.Error(err)
+}
+
+func TestSupported(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(Supported("a/go.sum"))
+	assert.True(Supported("package.json"))
+	assert.True(Supported("Gemfile.lock"))
+	assert.False(Supported("go.mod"))
+	assert.False(Supported("Gemfile"))
+}
+
+func TestReadFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"go.sum", "go.mod"}, ReadFiles("go.sum"))
+	assert.Equal([]string{"package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml"}, ReadFiles("package.json"))
+	assert.Equal([]string{"poetry.lock", "pyproject.toml"}, ReadFiles("poetry.lock"))
+	assert.Equal([]string{"Cargo.lock"}, ReadFiles("Cargo.lock"))
+}
+
+func TestInventory(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files, err := Files("testdata")
+	require.NoError(err)
+	assert.Contains(files, "testdata/go/go.sum")
+	assert.Contains(files, "testdata/yarn-berry/yarn.lock")
+	assert.NotContains(files, "testdata/npm/package.json")
+
+	deps, err := Inventory("testdata/rust")
+	require.NoError(err)
+	assert.Len(deps, 4)
+	_, err = Inventory("testdata/missing")
+	assert.Error(err)
+}
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]Dependency{
+		{PyPI, "a", "1.0.0", false},
+		{Npm, "a", "1.0.0", true},
+		{Npm, "b", "1.0.0", false},
+	}, Merge([]Dependency{
+		{PyPI, "a", "1.0.0", false},
+		{Npm, "b", "1.0.0", false},
+		{Npm, "a", "1.0.0", false},
+		{Npm, "a", "1.0.0", true},
+		{Npm, "c", "", true},
+	}))
+}
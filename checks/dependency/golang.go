@@ -0,0 +1,178 @@
+package dependency
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type goRequire struct {
+	Version  string
+	Indirect bool
+}
+
+type goReplace struct {
+	Name string
+	// Version is empty if replaced by a local directory
+	Version string
+}
+
+// goMod is the requirements and replacements in go.mod
+type goMod struct {
+	Requires map[string]goRequire
+	// Replaces are keyed by "module" or "module@version"
+	Replaces map[string]goReplace
+}
+
+func parseGoMod(path string) (*goMod, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mod := &goMod{
+		Requires: make(map[string]goRequire),
+		Replaces: make(map[string]goReplace),
+	}
+	block := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment = strings.TrimSpace(line[i+2:])
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		directive := block
+		if block == "" {
+			switch {
+			case len(fields) == 2 && fields[1] == "(":
+				block = fields[0]
+				continue
+			case fields[0] == "require" || fields[0] == "replace":
+				directive = fields[0]
+				fields = fields[1:]
+			default:
+				continue
+			}
+		} else if line == ")" {
+			block = ""
+			continue
+		}
+		switch directive {
+		case "require":
+			if len(fields) >= 2 {
+				mod.Requires[unquote(fields[0])] = goRequire{
+					Version:  fields[1],
+					Indirect: comment == "indirect" || strings.HasPrefix(comment, "indirect;"),
+				}
+			}
+		case "replace":
+			// old [version] => new [version]
+			i := indexOf(fields, "=>")
+			if i < 1 || i == len(fields)-1 {
+				continue
+			}
+			old := unquote(fields[0])
+			if i == 2 {
+				old += "@" + fields[1]
+			}
+			r := goReplace{Name: unquote(fields[i+1])}
+			if i+2 < len(fields) {
+				r.Version = fields[i+2]
+			}
+			mod.Replaces[old] = r
+		}
+	}
+	return mod, s.Err()
+}
+
+// parseGoSum parses the modules in go.sum and the go.mod next to it. The
+// modules with only the go.mod checksum are not built into the program and
+// so are skipped. The versions in go.mod are preferred, otherwise the highest
+// version in go.sum is used.
+func parseGoSum(path string) ([]Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	versions := make(map[string]string)
+	var names []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		name, ver := fields[0], fields[1]
+		if v, ok := versions[name]; !ok {
+			names = append(names, name)
+			versions[name] = ver
+		} else if CompareVersions(ver, v) > 0 {
+			versions[name] = ver
+		}
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+
+	mod := &goMod{}
+	modPath := filepath.Join(filepath.Dir(path), "go.mod")
+	if _, err := os.Stat(modPath); err == nil {
+		if mod, err = parseGoMod(modPath); err != nil {
+			return nil, err
+		}
+	}
+	for name, req := range mod.Requires {
+		if _, ok := versions[name]; !ok {
+			names = append(names, name)
+		}
+		versions[name] = req.Version
+	}
+
+	deps := make([]Dependency, 0, len(names))
+	for _, name := range names {
+		d := Dependency{
+			Ecosystem: Go,
+			Name:      name,
+			Version:   versions[name],
+		}
+		if req, ok := mod.Requires[name]; ok {
+			d.Direct = !req.Indirect
+		}
+		r, ok := mod.Replaces[name+"@"+d.Version]
+		if !ok {
+			r, ok = mod.Replaces[name]
+		}
+		if ok {
+			if r.Version == "" {
+				// replaced by a local directory
+				continue
+			}
+			d.Name, d.Version = r.Name, r.Version
+		}
+		deps = append(deps, d)
+	}
+	return deps, nil
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, "\"`")
+}
+
+func indexOf(fields []string, s string) int {
+	for i, f := range fields {
+		if f == s {
+			return i
+		}
+	}
+	return -1
+}
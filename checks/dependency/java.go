@@ -0,0 +1,101 @@
+package dependency
+
+import (
+	"encoding/xml"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+type pomProject struct {
+	GroupID string `xml:"groupId"`
+	Version string `xml:"version"`
+	Parent  struct {
+		GroupID string `xml:"groupId"`
+		Version string `xml:"version"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+}
+
+var pomPropertyRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parsePom parses the dependencies declared in pom.xml, they are all direct as
+// the transitive ones are only known by resolving with the repositories.
+// The dependencies without a resolvable version are skipped.
+func parsePom(path string) ([]Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var project pomProject
+	if err = xml.NewDecoder(f).Decode(&project); err != nil {
+		return nil, err
+	}
+
+	props := map[string]string{
+		"project.groupId":        project.GroupID,
+		"project.version":        project.Version,
+		"project.parent.groupId": project.Parent.GroupID,
+		"project.parent.version": project.Parent.Version,
+	}
+	if props["project.groupId"] == "" {
+		props["project.groupId"] = project.Parent.GroupID
+	}
+	if props["project.version"] == "" {
+		props["project.version"] = project.Parent.Version
+	}
+	for _, e := range project.Properties.Entries {
+		props[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+	resolve := func(s string) string {
+		// the properties may refer to each other
+		for i := 0; i < 10 && strings.Contains(s, "${"); i++ {
+			s = pomPropertyRegexp.ReplaceAllStringFunc(s, func(m string) string {
+				if v, ok := props[m[2:len(m)-1]]; ok {
+					return v
+				}
+				return m
+			})
+		}
+		return strings.TrimSpace(s)
+	}
+
+	managed := make(map[string]string)
+	for _, d := range project.DependencyManagement {
+		managed[resolve(d.GroupID)+":"+resolve(d.ArtifactID)] = resolve(d.Version)
+	}
+	var deps []Dependency
+	for _, d := range project.Dependencies {
+		name := resolve(d.GroupID) + ":" + resolve(d.ArtifactID)
+		version := resolve(d.Version)
+		if version == "" {
+			version = managed[name]
+		}
+		if version == "" || strings.Contains(version, "${") || strings.ContainsAny(version, "[(,") {
+			// unresolved or a version range
+			continue
+		}
+		deps = append(deps, Dependency{
+			Ecosystem: Maven,
+			Name:      name,
+			Version:   version,
+			Direct:    true,
+		})
+	}
+	return deps, nil
+}
@@ -0,0 +1,181 @@
+package dependency
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Requirement is a direct dependency declared in a manifest
+type Requirement struct {
+	Ecosystem string
+	Name      string
+	// Constraint is the declared version or range, e.g. "^1.2.0"
+	Constraint string
+}
+
+// Version returns the lowest version allowed by the constraint, e.g. "1.2"
+// of "~1.2.x", or of its last alternative like "^2.0" of "^1.0 || ^2.0". It
+// is empty if the constraint is not a version, e.g. "*", "dev-master" or urls.
+func (r Requirement) Version() string {
+	alternatives := constraintOrRegexp.Split(r.Constraint, -1)
+	c := alternatives[len(alternatives)-1]
+	for _, f := range strings.FieldsFunc(c, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if strings.HasPrefix(f, "<") || strings.HasPrefix(f, "!=") {
+			continue
+		}
+		v := strings.TrimLeft(f, "^~>=")
+		// the composer stability flags like "1.2.*@dev"
+		if i := strings.IndexByte(v, '@'); i >= 0 {
+			v = v[:i]
+		}
+		// wildcards like "1.2.x" or "1.*"
+		parts := strings.Split(v, ".")
+		for i, p := range parts {
+			if p == "x" || p == "X" || p == "*" {
+				parts = parts[:i]
+				break
+			}
+		}
+		v = strings.Join(parts, ".")
+		if ValidVersion(v) {
+			return v
+		}
+	}
+	return ""
+}
+
+// constraintOrRegexp splits the alternatives of npm "||" and composer "|"
+var constraintOrRegexp = regexp.MustCompile(`\s*\|\|?\s*`)
+
+// manifestParsers maps the manifest names to their parsers
+var manifestParsers = map[string]func(path string) ([]Requirement, error){
+	"go.mod":        parseGoModRequirements,
+	"package.json":  parsePackageJSONRequirements,
+	"composer.json": parseComposerJSONRequirements,
+}
+
+// IsManifest reports whether the file is a manifest declaring the direct
+// dependencies, whose requirements can be parsed
+func IsManifest(path string) bool {
+	_, ok := manifestParsers[filepath.Base(path)]
+	return ok
+}
+
+// Manifests returns the manifests in the repo, the installed packages and
+// the hidden directories are skipped.
+func Manifests(repoPath string) ([]string, error) {
+	return findFiles(repoPath, func(name string) bool {
+		_, ok := manifestParsers[name]
+		return ok
+	})
+}
+
+// ParseManifest parses the direct dependencies declared in the manifest,
+// sorted by the names
+func ParseManifest(path string) ([]Requirement, error) {
+	parse, ok := manifestParsers[filepath.Base(path)]
+	if !ok {
+		return nil, nil
+	}
+	reqs, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].Name < reqs[j].Name
+	})
+	return reqs, nil
+}
+
+// parseGoModRequirements parses the direct requirements in go.mod, the
+// modules replaced by local directories are skipped
+func parseGoModRequirements(path string) ([]Requirement, error) {
+	mod, err := parseGoMod(path)
+	if err != nil {
+		return nil, err
+	}
+	var reqs []Requirement
+	for name, req := range mod.Requires {
+		if req.Indirect {
+			continue
+		}
+		r := Requirement{Ecosystem: Go, Name: name, Constraint: req.Version}
+		replace, ok := mod.Replaces[name+"@"+req.Version]
+		if !ok {
+			replace, ok = mod.Replaces[name]
+		}
+		if ok {
+			if replace.Version == "" {
+				continue
+			}
+			r.Name, r.Constraint = replace.Name, replace.Version
+		}
+		reqs = append(reqs, r)
+	}
+	return reqs, nil
+}
+
+// parsePackageJSONRequirements parses the dependencies in package.json, the
+// aliases, urls and workspace packages are kept with no version
+func parsePackageJSONRequirements(path string) ([]Requirement, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err = json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var reqs []Requirement
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies} {
+		for name, c := range deps {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if strings.Contains(c, ":") {
+				// like "npm:other@^1.0", "file:../pkg" or "workspace:*"
+				c = ""
+			}
+			reqs = append(reqs, Requirement{Ecosystem: Npm, Name: name, Constraint: c})
+		}
+	}
+	return reqs, nil
+}
+
+// parseComposerJSONRequirements parses the packages required in
+// composer.json, the platform packages like "php" and "ext-json" are skipped
+func parseComposerJSONRequirements(path string) ([]Requirement, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var reqs []Requirement
+	for _, m := range []map[string]string{manifest.Require, manifest.RequireDev} {
+		for name, c := range m {
+			if seen[name] || !strings.Contains(name, "/") {
+				continue
+			}
+			seen[name] = true
+			reqs = append(reqs, Requirement{Ecosystem: Packagist, Name: name, Constraint: c})
+		}
+	}
+	return reqs, nil
+}
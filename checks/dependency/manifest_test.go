@@ -0,0 +1,69 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	reqs, err := ParseManifest("testdata/go/go.mod")
+	require.NoError(err)
+	assert.Equal([]Requirement{
+		{Go, "github.com/gin-gonic/gin", "v1.5.0"},
+		{Go, "github.com/stretchr/testify", "v1.4.0"},
+	}, reqs)
+
+	reqs, err = ParseManifest("testdata/npm/package.json")
+	require.NoError(err)
+	assert.Equal([]Requirement{
+		{Npm, "express", "^4.17.1"},
+		{Npm, "mocha", "^8.0.0"},
+	}, reqs)
+
+	reqs, err = ParseManifest("testdata/php/composer.json")
+	require.NoError(err)
+	assert.Equal([]Requirement{
+		{Packagist, "monolog/monolog", "^1.0"},
+		{Packagist, "phpunit/phpunit", "^8.0"},
+	}, reqs)
+
+	reqs, err = ParseManifest("testdata/php/composer.lock")
+	require.NoError(err)
+	assert.Empty(reqs)
+
+	assert.True(IsManifest("a/go.mod"))
+	assert.False(IsManifest("a/go.sum"))
+}
+
+func TestRequirementVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	for c, v := range map[string]string{
+		"v1.5.0":           "v1.5.0",
+		"^4.17.1":          "4.17.1",
+		"~1.2.x":           "1.2",
+		"1.*":              "1",
+		">=1.2 <2":         "1.2",
+		"<2, >=1.4":        "1.4",
+		"^1.0 || ^2.0":     "2.0",
+		"^1.0|^2.3":        "2.3",
+		"1.2.*@dev":        "1.2",
+		"1.2.3 - 2.0.0":    "1.2.3",
+		"*":                "",
+		"dev-master":       "",
+		"latest":           "",
+		"":                 "",
+		"github:user/repo": "",
+		"2.0.0-beta.1":     "2.0.0-beta.1",
+		"=v3.0.0":          "v3.0.0",
+		"x":                "",
+		">= 2.1.0, != 2.2": "2.1.0",
+	} {
+		assert.Equal(v, Requirement{Constraint: c}.Version(), c)
+	}
+}
@@ -0,0 +1,273 @@
+package dependency
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// nodeDirect returns the names of the dependencies in package.json in dir
+func nodeDirect(dir string) (map[string]bool, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err = json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+	direct := make(map[string]bool)
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies} {
+		for name := range deps {
+			direct[name] = true
+		}
+	}
+	return direct, nil
+}
+
+// nodeModulesName returns the package name of the install path like
+// "node_modules/a/node_modules/@scope/b"
+func nodeModulesName(path string) string {
+	i := strings.LastIndex(path, "node_modules/")
+	if i < 0 {
+		return ""
+	}
+	return path[i+len("node_modules/"):]
+}
+
+// parsePackageLock parses package-lock.json of all the lockfile versions
+func parsePackageLock(path string) ([]Dependency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type dependency struct {
+		Version      string                `json:"version"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	type pkg struct {
+		Name                 string            `json:"name"`
+		Version              string            `json:"version"`
+		Link                 bool              `json:"link"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	var lock struct {
+		// Packages is lockfileVersion 2 and later, keyed by the install path
+		Packages     map[string]pkg        `json:"packages"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	if err = json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	var direct map[string]bool
+	if root, ok := lock.Packages[""]; ok {
+		direct = make(map[string]bool)
+		for _, deps := range []map[string]string{root.Dependencies, root.DevDependencies, root.OptionalDependencies} {
+			for name := range deps {
+				direct[name] = true
+			}
+		}
+	} else if direct, err = nodeDirect(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	var deps []Dependency
+	if len(lock.Packages) > 0 {
+		for installPath, p := range lock.Packages {
+			name := nodeModulesName(installPath)
+			if name == "" || p.Link {
+				// the root project, the workspaces or their links
+				continue
+			}
+			if p.Name != "" {
+				// aliased packages
+				name = p.Name
+			}
+			deps = append(deps, Dependency{
+				Ecosystem: Npm,
+				Name:      name,
+				Version:   p.Version,
+				Direct:    installPath == "node_modules/"+name && direct[name],
+			})
+		}
+		return deps, nil
+	}
+	var walk func(deps map[string]dependency, top bool)
+	walk = func(m map[string]dependency, top bool) {
+		for name, d := range m {
+			deps = append(deps, Dependency{
+				Ecosystem: Npm,
+				Name:      name,
+				Version:   d.Version,
+				Direct:    top && direct[name],
+			})
+			walk(d.Dependencies, false)
+		}
+	}
+	walk(lock.Dependencies, true)
+	return deps, nil
+}
+
+// yarnSpecName returns the package name of the spec like "@scope/name@^1.0.0"
+// or "name@npm:^1.0.0"
+func yarnSpecName(spec string) string {
+	spec = strings.Trim(strings.TrimSpace(spec), `"`)
+	i := strings.LastIndex(spec, "@")
+	if i <= 0 {
+		return spec
+	}
+	name := spec[:i]
+	// "name@npm:^1.0.0" has been cut to "name@npm"
+	if j := strings.LastIndex(name, "@"); j > 0 {
+		name = name[:j]
+	}
+	return name
+}
+
+// parseYarnLock parses yarn.lock of yarn v1 and the later versions
+func parseYarnLock(path string) ([]Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	direct, err := nodeDirect(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []Dependency
+	name := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			// the header of an entry like `"a@^1.0.0", a@^1.1.0:`
+			name = ""
+			header := strings.TrimSuffix(line, ":")
+			if header == "__metadata" {
+				continue
+			}
+			spec := strings.Split(header, ",")[0]
+			if strings.Contains(spec, "@workspace:") || strings.Contains(spec, "@link:") ||
+				strings.Contains(spec, "@portal:") || strings.Contains(spec, "@file:") {
+				continue
+			}
+			name = yarnSpecName(spec)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		field := strings.TrimSpace(line)
+		if strings.HasPrefix(field, "version ") || strings.HasPrefix(field, "version:") {
+			version := strings.TrimSpace(strings.TrimPrefix(field, "version"))
+			version = strings.Trim(strings.TrimSpace(strings.TrimPrefix(version, ":")), `"`)
+			deps = append(deps, Dependency{
+				Ecosystem: Npm,
+				Name:      name,
+				Version:   version,
+				Direct:    direct[name],
+			})
+			name = ""
+		}
+	}
+	return deps, s.Err()
+}
+
+// pnpmVersion strips the peer dependencies suffix like "1.0.0(react@18.0.0)"
+// or "1.0.0_react@18.0.0" from the version
+func pnpmVersion(v string) string {
+	if i := strings.IndexAny(v, "(_"); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// pnpmPackage parses the package key like "/name/1.0.0_peer@1.0.0" of
+// lockfile v5, "/name@1.0.0(peer@1.0.0)" of v6, or "name@1.0.0" of v9
+func pnpmPackage(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if i := strings.IndexByte(key, '('); i >= 0 {
+		key = key[:i]
+	}
+	if i := strings.LastIndex(key, "/"); i > 0 && i+1 < len(key) && key[i+1] >= '0' && key[i+1] <= '9' {
+		return key[:i], pnpmVersion(key[i+1:])
+	}
+	if i := strings.LastIndex(key, "@"); i > 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", ""
+}
+
+// parsePnpmLock parses pnpm-lock.yaml
+func parsePnpmLock(path string) ([]Dependency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type importer struct {
+		Dependencies         map[string]interface{} `yaml:"dependencies"`
+		DevDependencies      map[string]interface{} `yaml:"devDependencies"`
+		OptionalDependencies map[string]interface{} `yaml:"optionalDependencies"`
+	}
+	var lock struct {
+		importer  `yaml:",inline"`
+		Importers map[string]importer    `yaml:"importers"`
+		Packages  map[string]interface{} `yaml:"packages"`
+	}
+	if err = yaml.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	// the resolved versions of the direct dependencies of the root project
+	direct := make(map[string]string)
+	root := lock.importer
+	if r, ok := lock.Importers["."]; ok {
+		root = r
+	}
+	for _, m := range []map[string]interface{}{root.Dependencies, root.DevDependencies, root.OptionalDependencies} {
+		for name, v := range m {
+			switch v := v.(type) {
+			case string:
+				direct[name] = pnpmVersion(v)
+			case map[interface{}]interface{}:
+				if version, ok := v["version"].(string); ok {
+					direct[name] = pnpmVersion(version)
+				}
+			}
+		}
+	}
+
+	deps := make([]Dependency, 0, len(lock.Packages))
+	for key := range lock.Packages {
+		name, version := pnpmPackage(key)
+		if name == "" {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Ecosystem: Npm,
+			Name:      name,
+			Version:   version,
+			Direct:    direct[name] == version,
+		})
+	}
+	return deps, nil
+}
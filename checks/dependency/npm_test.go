@@ -0,0 +1,31 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPnpmPackage(t *testing.T) {
+	assert := assert.New(t)
+
+	for key, expected := range map[string][2]string{
+		"/lodash/4.17.21":                 {"lodash", "4.17.21"},
+		"/@babel/core/7.12.3":             {"@babel/core", "7.12.3"},
+		"/react-dom/17.0.2_react@17.0.2":  {"react-dom", "17.0.2"},
+		"/react-dom@17.0.2(react@17.0.2)": {"react-dom", "17.0.2"},
+		"/@types/node@14.0.0":             {"@types/node", "14.0.0"},
+		"@types/node@14.0.0":              {"@types/node", "14.0.0"},
+	} {
+		name, version := pnpmPackage(key)
+		assert.Equal(expected, [2]string{name, version}, key)
+	}
+}
+
+func TestYarnSpecName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("lodash", yarnSpecName("lodash@^4.17.0"))
+	assert.Equal("@babel/core", yarnSpecName(`"@babel/core@^7.0.0"`))
+	assert.Equal("ms", yarnSpecName("ms@npm:2.1.2"))
+}
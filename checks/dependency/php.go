@@ -0,0 +1,58 @@
+package dependency
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// parseComposerLock parses the installed packages in composer.lock, the direct
+// ones are required in composer.json next to it.
+func parseComposerLock(path string) ([]Dependency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var lock struct {
+		Packages    []pkg `json:"packages"`
+		PackagesDev []pkg `json:"packages-dev"`
+	}
+	if err = json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	direct := make(map[string]bool)
+	content, err = ioutil.ReadFile(filepath.Join(filepath.Dir(path), "composer.json"))
+	if err == nil {
+		var manifest struct {
+			Require    map[string]string `json:"require"`
+			RequireDev map[string]string `json:"require-dev"`
+		}
+		if err = json.Unmarshal(content, &manifest); err != nil {
+			return nil, err
+		}
+		for _, m := range []map[string]string{manifest.Require, manifest.RequireDev} {
+			for name := range m {
+				direct[name] = true
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	deps := make([]Dependency, 0, len(lock.Packages)+len(lock.PackagesDev))
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		deps = append(deps, Dependency{
+			Ecosystem: Packagist,
+			Name:      p.Name,
+			Version:   p.Version,
+			Direct:    direct[p.Name],
+		})
+	}
+	return deps, nil
+}
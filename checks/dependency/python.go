@@ -0,0 +1,142 @@
+package dependency
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var pythonNameRegexp = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes the name as PEP 503
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameRegexp.ReplaceAllString(strings.TrimSpace(name), "-"))
+}
+
+// pythonRequirementName returns the name of the requirement like "a[extra]>=1.0"
+func pythonRequirementName(req string) string {
+	end := strings.IndexAny(req, "[<>=!~; (")
+	if end >= 0 {
+		req = req[:end]
+	}
+	return normalizePythonName(req)
+}
+
+// parseRequirements parses the pinned requirements like "name==1.0.0" in
+// requirements.txt, the requirements with version ranges are skipped.
+func parseRequirements(path string) ([]Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var deps []Dependency
+	line := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		text := s.Text()
+		if strings.HasSuffix(text, "\\") {
+			line += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+		line += text
+		req := line
+		line = ""
+		if i := strings.Index(req, " #"); i >= 0 {
+			req = req[:i]
+		}
+		if i := strings.IndexByte(req, ';'); i >= 0 {
+			// environment markers
+			req = req[:i]
+		}
+		req = strings.TrimSpace(req)
+		if req == "" || strings.HasPrefix(req, "#") || strings.HasPrefix(req, "-") {
+			continue
+		}
+		// strip the options like --hash
+		if i := strings.Index(req, " -"); i >= 0 {
+			req = strings.TrimSpace(req[:i])
+		}
+		i := strings.Index(req, "==")
+		if i < 0 {
+			continue
+		}
+		version := strings.TrimSpace(strings.TrimPrefix(req[i+2:], "="))
+		if version == "" || strings.ContainsAny(version, "*,<>") {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Ecosystem: PyPI,
+			Name:      pythonRequirementName(req[:i]),
+			Version:   version,
+			Direct:    true,
+		})
+	}
+	return deps, s.Err()
+}
+
+// pyprojectDirect returns the names of the dependencies in pyproject.toml in dir
+func pyprojectDirect(dir string) (map[string]bool, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	tables, err := parseTOML(content)
+	if err != nil {
+		return nil, err
+	}
+	direct := make(map[string]bool)
+	for _, t := range tables {
+		switch {
+		case t.Name == "tool.poetry.dependencies" || t.Name == "tool.poetry.dev-dependencies" ||
+			strings.HasPrefix(t.Name, "tool.poetry.group.") && strings.HasSuffix(t.Name, ".dependencies"):
+			for _, key := range t.Keys {
+				if key != "python" {
+					direct[normalizePythonName(key)] = true
+				}
+			}
+		case t.Name == "project":
+			for _, req := range t.Arrays["dependencies"] {
+				direct[pythonRequirementName(req)] = true
+			}
+		}
+	}
+	return direct, nil
+}
+
+// parsePoetryLock parses the packages in poetry.lock, the direct ones are
+// declared in pyproject.toml next to it.
+func parsePoetryLock(path string) ([]Dependency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := parseTOML(content)
+	if err != nil {
+		return nil, err
+	}
+	direct, err := pyprojectDirect(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	var deps []Dependency
+	for _, t := range tables {
+		if t.Name != "package" || !t.Array {
+			continue
+		}
+		name := normalizePythonName(t.Values["name"])
+		deps = append(deps, Dependency{
+			Ecosystem: PyPI,
+			Name:      name,
+			Version:   t.Values["version"],
+			Direct:    direct[name],
+		})
+	}
+	return deps, nil
+}
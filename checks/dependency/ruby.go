@@ -0,0 +1,66 @@
+package dependency
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// parseGemfileLock parses the gems from the rubygems sources in Gemfile.lock,
+// the direct ones are listed in the DEPENDENCIES section.
+func parseGemfileLock(path string) ([]Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var deps []Dependency
+	direct := make(map[string]bool)
+	section := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = strings.TrimSpace(line)
+			continue
+		}
+		switch section {
+		case "GEM":
+			// the specs are indented by 4 spaces like "    rack (2.2.3)",
+			// and their dependencies by 6 spaces
+			if !strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "     ") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) != 2 || !strings.HasPrefix(fields[1], "(") {
+				continue
+			}
+			version := strings.Trim(fields[1], "()")
+			if i := strings.IndexByte(version, '-'); i >= 0 {
+				// the platform like "1.13.10-x86_64-linux"
+				version = version[:i]
+			}
+			deps = append(deps, Dependency{
+				Ecosystem: RubyGems,
+				Name:      fields[0],
+				Version:   version,
+			})
+		case "DEPENDENCIES":
+			fields := strings.Fields(line)
+			if len(fields) > 0 {
+				direct[strings.TrimSuffix(fields[0], "!")] = true
+			}
+		}
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	for i := range deps {
+		deps[i].Direct = direct[deps[i].Name]
+	}
+	return deps, nil
+}
@@ -0,0 +1,59 @@
+package dependency
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// parseCargoLock parses the crates in Cargo.lock, the crates without a source
+// are the local ones of the workspace, whose dependencies are direct.
+func parseCargoLock(path string) ([]Dependency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := parseTOML(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// the dependencies of the local crates, like "name" or "name version (source)"
+	direct := make(map[string]bool)
+	for _, t := range tables {
+		if t.Name == "package" && t.Values["source"] == "" {
+			for _, dep := range t.Arrays["dependencies"] {
+				fields := strings.Fields(dep)
+				direct[fields[0]] = true
+				if len(fields) > 1 {
+					direct[fields[0]+" "+fields[1]] = true
+				}
+			}
+		}
+	}
+
+	var deps []Dependency
+	for _, t := range tables {
+		if t.Name != "package" || !t.Array || t.Values["source"] == "" {
+			continue
+		}
+		name, version := t.Values["name"], t.Values["version"]
+		deps = append(deps, Dependency{
+			Ecosystem: Cargo,
+			Name:      name,
+			Version:   version,
+			// the version is specified if there are multiple versions of the crate
+			Direct: direct[name+" "+version] || direct[name] && !hasMultipleVersions(tables, name),
+		})
+	}
+	return deps, nil
+}
+
+func hasMultipleVersions(tables []*tomlTable, name string) bool {
+	n := 0
+	for _, t := range tables {
+		if t.Name == "package" && t.Values["name"] == name {
+			n++
+		}
+	}
+	return n > 1
+}
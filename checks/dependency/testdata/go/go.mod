@@ -0,0 +1,15 @@
+module example.com/app
+
+go 1.13
+
+require (
+	github.com/gin-gonic/gin v1.5.0
+	golang.org/x/text v0.3.2 // indirect
+	example.com/local v0.0.0
+)
+
+require github.com/stretchr/testify v1.4.0
+
+replace example.com/local => ../local
+
+replace golang.org/x/net => golang.org/x/net v0.0.0-20220906165146-f3363e06e74c
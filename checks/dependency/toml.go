@@ -0,0 +1,127 @@
+package dependency
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// tomlTable is a table of a TOML file, only the keys with the string values
+// and the arrays of strings are parsed, which is enough for the lockfiles.
+type tomlTable struct {
+	Name string
+	// Array is true for the tables like [[package]]
+	Array  bool
+	Keys   []string
+	Values map[string]string
+	Arrays map[string][]string
+}
+
+func newTOMLTable(name string, array bool) *tomlTable {
+	return &tomlTable{
+		Name:   name,
+		Array:  array,
+		Values: make(map[string]string),
+		Arrays: make(map[string][]string),
+	}
+}
+
+// parseTOML parses the tables in the content, the keys before any table
+// header are in the table with the empty name.
+func parseTOML(content []byte) ([]*tomlTable, error) {
+	table := newTOMLTable("", false)
+	tables := []*tomlTable{table}
+	s := bufio.NewScanner(bytes.NewReader(content))
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := strings.TrimSpace(stripTOMLComment(s.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+			table = newTOMLTable(strings.TrimSpace(line[2:len(line)-2]), true)
+			tables = append(tables, table)
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = newTOMLTable(strings.TrimSpace(line[1:len(line)-1]), false)
+			tables = append(tables, table)
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			continue
+		}
+		key := tomlString(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+		if strings.HasPrefix(value, "[") {
+			// the array may span multiple lines
+			for !strings.HasSuffix(value, "]") && s.Scan() {
+				value += " " + strings.TrimSpace(stripTOMLComment(s.Text()))
+			}
+			table.Arrays[key] = tomlArray(value)
+		} else {
+			table.Values[key] = tomlString(value)
+		}
+		table.Keys = append(table.Keys, key)
+	}
+	return tables, s.Err()
+}
+
+// stripTOMLComment removes the comment outside of the strings
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlString unquotes the basic or literal string
+func tomlString(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		s = s[1 : len(s)-1]
+		if !strings.Contains(s, "\\") {
+			return s
+		}
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s)
+	}
+	return s
+}
+
+// tomlArray splits the array of strings
+func tomlArray(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	var items []string
+	var quote byte
+	start := -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				items = append(items, tomlString(s[start:i+1]))
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			start = i
+		}
+	}
+	return items
+}
@@ -0,0 +1,36 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTOML(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tables, err := parseTOML([]byte(`version = 3 # comment
+[project]
+name = "app#1"
+dependencies = [
+  "requests>=2.0", # comment
+  'flask',
+]
+
+[[package]]
+name = 'a'
+`))
+	require.NoError(err)
+	require.Len(tables, 3)
+	assert.Equal("", tables[0].Name)
+	assert.Equal("3", tables[0].Values["version"])
+	assert.Equal("project", tables[1].Name)
+	assert.False(tables[1].Array)
+	assert.Equal("app#1", tables[1].Values["name"])
+	assert.Equal([]string{"requests>=2.0", "flask"}, tables[1].Arrays["dependencies"])
+	assert.Equal([]string{"name", "dependencies"}, tables[1].Keys)
+	assert.True(tables[2].Array)
+	assert.Equal("a", tables[2].Values["name"])
+}
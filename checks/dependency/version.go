@@ -0,0 +1,147 @@
+package dependency
+
+import (
+	"strconv"
+	"strings"
+)
+
+// version is a leniently parsed semantic version, it accepts the "v" prefix,
+// any number of the release components like "1.2" or "1.2.3.4", and Go
+// pseudo-versions like "v0.0.0-20200101000000-abcdef123456", which are
+// ordered by their prerelease part as semver does.
+type version struct {
+	release []int64
+	pre     []string
+}
+
+// parseVersion parses v, the build metadata like "+incompatible" is ignored
+func parseVersion(v string) (version, bool) {
+	v = strings.TrimSpace(v)
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var ver version
+	release := v
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		release = v[:i]
+		if v[i+1:] == "" {
+			return ver, false
+		}
+		ver.pre = strings.Split(v[i+1:], ".")
+	}
+	if release == "" {
+		return ver, false
+	}
+	for _, s := range strings.Split(release, ".") {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			return ver, false
+		}
+		ver.release = append(ver.release, n)
+	}
+	return ver, true
+}
+
+// compare returns -1, 0 or 1 if v is less than, equal to or greater than w
+func (v version) compare(w version) int {
+	n := len(v.release)
+	if len(w.release) > n {
+		n = len(w.release)
+	}
+	for i := 0; i < n; i++ {
+		var a, b int64
+		if i < len(v.release) {
+			a = v.release[i]
+		}
+		if i < len(w.release) {
+			b = w.release[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	// a version without prerelease is greater than the one with
+	switch {
+	case len(v.pre) == 0 && len(w.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(w.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(w.pre); i++ {
+		if c := comparePrerelease(v.pre[i], w.pre[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.pre) < len(w.pre):
+		return -1
+	case len(v.pre) > len(w.pre):
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease compares the prerelease identifiers, numeric ones are
+// lower than alphanumeric ones
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// ValidVersion reports whether v can be compared as a version
+func ValidVersion(v string) bool {
+	_, ok := parseVersion(v)
+	return ok
+}
+
+// MajorMinor returns the major and minor components of v, the minor is 0 if
+// v has only the major component
+func MajorMinor(v string) (major, minor int64, ok bool) {
+	ver, ok := parseVersion(v)
+	if !ok {
+		return 0, 0, false
+	}
+	if len(ver.release) > 1 {
+		minor = ver.release[1]
+	}
+	return ver.release[0], minor, true
+}
+
+// Prerelease reports whether v is a prerelease, e.g. "1.0.0-rc.1" or the Go
+// pseudo-versions
+func Prerelease(v string) bool {
+	ver, ok := parseVersion(v)
+	return ok && len(ver.pre) > 0
+}
+
+// CompareVersions compares the version strings, the unparsable ones are
+// compared as strings
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if okA && okB {
+		return va.compare(vb)
+	}
+	return strings.Compare(a, b)
+}
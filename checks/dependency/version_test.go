@@ -0,0 +1,45 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, CompareVersions("v1.2.3", "1.2.3"))
+	assert.Equal(0, CompareVersions("1.2", "1.2.0"))
+	assert.Equal(-1, CompareVersions("1.2.3", "1.10.0"))
+	assert.Equal(1, CompareVersions("1.2.3.1", "1.2.3"))
+	assert.Equal(-1, CompareVersions("1.0.0-alpha", "1.0.0"))
+	assert.Equal(-1, CompareVersions("1.0.0-alpha.1", "1.0.0-alpha.beta"))
+	assert.Equal(-1, CompareVersions("1.0.0-2", "1.0.0-10"))
+	assert.Equal(0, CompareVersions("v2.0.0+incompatible", "2.0.0"))
+	// Go pseudo-versions
+	assert.Equal(-1, CompareVersions("v0.0.0-20190620200207-3b0461eec859", "0.0.0-20220906165146-f3363e06e74c"))
+	assert.Equal(1, CompareVersions("v0.3.1-0.20190101000000-abcdefabcdef", "0.3.0"))
+	assert.Equal(-1, CompareVersions("v0.3.1-0.20190101000000-abcdefabcdef", "0.3.1"))
+
+	_, ok := parseVersion("dev-master")
+	assert.False(ok)
+}
+
+func TestMajorMinor(t *testing.T) {
+	assert := assert.New(t)
+
+	major, minor, ok := MajorMinor("v1.5.0")
+	assert.True(ok)
+	assert.Equal([]int64{1, 5}, []int64{major, minor})
+	major, minor, ok = MajorMinor("2")
+	assert.True(ok)
+	assert.Equal([]int64{2, 0}, []int64{major, minor})
+	_, _, ok = MajorMinor("dev-master")
+	assert.False(ok)
+
+	assert.True(Prerelease("1.0.0-rc.1"))
+	assert.True(Prerelease("v0.0.0-20190620200207-3b0461eec859"))
+	assert.False(Prerelease("v2.0.0+incompatible"))
+	assert.False(Prerelease("dev-master"))
+}
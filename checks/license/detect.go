@@ -0,0 +1,50 @@
+package license
+
+import (
+	"regexp"
+	"strings"
+)
+
+// detector detects a license by the phrases all present in its text
+type detector struct {
+	ID      string
+	Phrases []string
+}
+
+// detectors are in the order of precedence, e.g. the LGPL texts mention the
+// GPL, so that they are detected before the GPL.
+var detectors = []detector{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"LGPL-2.0", []string{"gnu library general public license", "version 2"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+var spaceRegexp = regexp.MustCompile(`\s+`)
+
+// Detect returns the SPDX ID of the license text, it is empty if unknown
+func Detect(text string) string {
+	text = spaceRegexp.ReplaceAllString(strings.ToLower(text), " ")
+	for _, d := range detectors {
+		found := true
+		for _, p := range d.Phrases {
+			if !strings.Contains(text, p) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return d.ID
+		}
+	}
+	return ""
+}
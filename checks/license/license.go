@@ -0,0 +1,168 @@
+package license
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/tengattack/unified-ci/checks/dependency"
+)
+
+// Result is the license of a dependency
+type Result struct {
+	dependency.Dependency
+	// License is the SPDX license expression, empty if unknown
+	License string
+	// Source is the file the license is resolved from, relative to the repo
+	Source string
+}
+
+// Resolve resolves the licenses of the dependencies of the repo from the
+// local metadata, that is the LICENSE files of the vendored Go modules, the
+// package.json files of the installed node modules and composer.lock.
+func Resolve(repoPath string) ([]Result, error) {
+	files, err := dependency.Files(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	seen := make(map[dependency.Dependency]bool)
+	for _, file := range files {
+		deps, err := dependency.Parse(file)
+		if err != nil {
+			rel, _ := filepath.Rel(repoPath, file)
+			return nil, &dependency.ParseError{File: filepath.ToSlash(rel), Err: err}
+		}
+		dir := filepath.Dir(file)
+		composer := composerLicenses(file)
+		for _, d := range deps {
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			r := Result{Dependency: d}
+			var source string
+			switch d.Ecosystem {
+			case dependency.Go:
+				r.License, source = goLicense(dir, d)
+			case dependency.Npm:
+				r.License, source = nodeLicense(dir, d)
+			case dependency.Packagist:
+				if l, ok := composer[d.Name]; ok {
+					r.License, source = l, file
+				}
+			}
+			if source != "" {
+				rel, _ := filepath.Rel(repoPath, source)
+				r.Source = filepath.ToSlash(rel)
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// licenseFiles are the names of the license files in the modules
+var licenseFiles = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md",
+	"COPYING", "COPYING.md", "COPYING.txt", "License", "license",
+}
+
+// goLicense detects the license of the module vendored in dir
+func goLicense(dir string, d dependency.Dependency) (string, string) {
+	moduleDir := filepath.Join(dir, "vendor", filepath.FromSlash(d.Name))
+	for _, name := range licenseFiles {
+		path := filepath.Join(moduleDir, name)
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := Detect(string(content)); id != "" {
+			return id, path
+		}
+	}
+	return "", ""
+}
+
+// nodeLicense reads the license of the node module installed in dir, the
+// nested modules are searched if the top one is of another version
+func nodeLicense(dir string, d dependency.Dependency) (string, string) {
+	paths := []string{filepath.Join(dir, "node_modules", filepath.FromSlash(d.Name), "package.json")}
+	nested, _ := filepath.Glob(filepath.Join(dir, "node_modules", "*", "node_modules", filepath.FromSlash(d.Name), "package.json"))
+	scoped, _ := filepath.Glob(filepath.Join(dir, "node_modules", "@*", "*", "node_modules", filepath.FromSlash(d.Name), "package.json"))
+	paths = append(append(paths, nested...), scoped...)
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Version  string          `json:"version"`
+			License  json.RawMessage `json:"license"`
+			Licenses json.RawMessage `json:"licenses"`
+		}
+		if json.Unmarshal(content, &pkg) != nil || pkg.Version != d.Version {
+			continue
+		}
+		return nodeLicenseField(pkg.License, pkg.Licenses), path
+	}
+	return "", ""
+}
+
+// nodeLicenseField parses the license field, which is an expression or the
+// deprecated objects like {"type": "MIT"}
+func nodeLicenseField(license, licenses json.RawMessage) string {
+	type object struct {
+		Type string `json:"type"`
+	}
+	var s string
+	if json.Unmarshal(license, &s) == nil {
+		return s
+	}
+	var o object
+	if json.Unmarshal(license, &o) == nil && o.Type != "" {
+		return o.Type
+	}
+	var list []object
+	if json.Unmarshal(licenses, &list) == nil {
+		var ids []string
+		for _, o := range list {
+			if o.Type != "" {
+				ids = append(ids, o.Type)
+			}
+		}
+		return strings.Join(ids, " OR ")
+	}
+	return ""
+}
+
+// composerLicenses reads the licenses of the packages in composer.lock,
+// a package with multiple licenses can be used under any of them
+func composerLicenses(file string) map[string]string {
+	if filepath.Base(file) != "composer.lock" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	type pkg struct {
+		Name    string   `json:"name"`
+		License []string `json:"license"`
+	}
+	var lock struct {
+		Packages    []pkg `json:"packages"`
+		PackagesDev []pkg `json:"packages-dev"`
+	}
+	if json.Unmarshal(content, &lock) != nil {
+		return nil
+	}
+	licenses := make(map[string]string)
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		if len(p.License) > 0 {
+			licenses[p.Name] = strings.Join(p.License, " OR ")
+		}
+	}
+	return licenses
+}
@@ -0,0 +1,52 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/checks/dependency"
+)
+
+func TestResolve(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	results, err := Resolve("testdata/repo")
+	require.NoError(err)
+	licenses := make(map[string][2]string)
+	for _, r := range results {
+		licenses[r.Name+"@"+r.Version] = [2]string{r.License, r.Source}
+	}
+	assert.Equal(map[string][2]string{
+		"github.com/a/mit@v1.0.0":     {"MIT", "vendor/github.com/a/mit/LICENSE"},
+		"github.com/b/gpl@v0.1.0":     {"GPL-3.0", "vendor/github.com/b/gpl/COPYING"},
+		"github.com/c/unknown@v2.0.0": {"", ""},
+		"left-pad@1.3.0":              {"WTFPL", "node_modules/left-pad/package.json"},
+		"left-pad@1.1.0":              {"BSD-3-Clause", "node_modules/old/node_modules/left-pad/package.json"},
+		"old@0.1.0":                   {"MIT OR Apache-2.0", "node_modules/old/package.json"},
+		"@scope/pkg@1.0.0":            {"(MIT OR GPL-3.0)", "node_modules/@scope/pkg/package.json"},
+		"monolog/monolog@1.25.1":      {"MIT", "composer.lock"},
+		"gpl/lib@2.0.0":               {"GPL-2.0-or-later", "composer.lock"},
+		"dual/lib@1.0.0":              {"LGPL-2.1-only OR GPL-3.0-only", "composer.lock"},
+	}, licenses)
+	for _, r := range results {
+		if r.Name == "left-pad" && r.Version == "1.3.0" {
+			assert.Equal(dependency.Npm, r.Ecosystem)
+			assert.True(r.Direct)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("Apache-2.0", Detect("\n  Apache License\n  Version 2.0, January 2004\n"))
+	assert.Equal("LGPL-2.1", Detect("GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999\n"+
+		"This license ... the GNU General Public License"))
+	assert.Equal("GPL-2.0", Detect("GNU GENERAL PUBLIC LICENSE\n  Version 2, June 1991"))
+	assert.Equal("BSD-3-Clause", Detect("Redistribution and use in source and binary forms, with or without\n"+
+		"modification ... Neither the name of the copyright holder"))
+	assert.Equal("BSD-2-Clause", Detect("Redistribution and use in source and binary forms, with or without"))
+	assert.Equal("", Detect("All rights reserved."))
+}
@@ -0,0 +1,135 @@
+package license
+
+import (
+	"strings"
+
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// Violation is a dependency whose license is not allowed
+type Violation struct {
+	Result
+	Reason string
+}
+
+// MDTitle returns the title of the markdown table used to report violations
+func (Violation) MDTitle() string {
+	return "|name|version|license|reason|\n|---|---|---|---|\n"
+}
+
+// MDTableRow formats Violation v into a row of the markdown table
+func (v Violation) MDTableRow() string {
+	license := v.License
+	if license == "" {
+		license = "unknown"
+	}
+	return "|" + v.Name + "|" + v.Version + "|" + license + "|" + v.Reason + "|\n"
+}
+
+// splitExpression splits the SPDX expression by the operator at the top level
+// of the parentheses, e.g. "(MIT OR Apache-2.0) AND BSD-3-Clause" by "AND"
+func splitExpression(expr, op string) []string {
+	var parts []string
+	depth, start := 0, 0
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	for i, f := range fields {
+		switch {
+		case f == "(":
+			depth++
+		case f == ")":
+			depth--
+		case depth == 0 && strings.EqualFold(f, op):
+			parts = append(parts, strings.Join(fields[start:i], " "))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.Join(fields[start:], " "))
+}
+
+// trimParens removes the parentheses around the whole expression
+func trimParens(expr string) string {
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		depth := 0
+		for i, c := range expr {
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+				if depth == 0 && i != len(expr)-1 {
+					// like "(MIT) OR (BSD-3-Clause)"
+					return expr
+				}
+			}
+		}
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// Evaluate checks the SPDX license expression against the config, the
+// licenses combined by "OR" can be chosen, while all the ones by "AND" apply.
+// It returns the reason if the license is not allowed.
+func Evaluate(expr string, conf util.LicenseConfig) (bool, string) {
+	expr = trimParens(strings.TrimSpace(expr))
+	alternatives := splitExpression(expr, "OR")
+	if len(alternatives) > 1 {
+		var reasons []string
+		for _, a := range alternatives {
+			ok, reason := Evaluate(a, conf)
+			if ok {
+				return true, ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return false, strings.Join(reasons, ", ")
+	}
+	terms := splitExpression(expr, "AND")
+	if len(terms) > 1 {
+		for _, t := range terms {
+			if ok, reason := Evaluate(t, conf); !ok {
+				return false, reason
+			}
+		}
+		return true, ""
+	}
+	// the exceptions like "GPL-2.0 WITH Classpath-exception-2.0" apply to the license
+	id := splitExpression(expr, "WITH")[0]
+	return conf.CheckLicense(strings.TrimSuffix(id, "+"))
+}
+
+// Check returns the dependencies whose licenses are not allowed by the config,
+// and the ones whose licenses are unknown
+func Check(results []Result, conf util.LicenseConfig) (violations []Violation, unknown []Result) {
+	for _, r := range results {
+		if r.License == "" {
+			unknown = append(unknown, r)
+			continue
+		}
+		if ok, reason := Evaluate(r.License, conf); !ok {
+			violations = append(violations, Violation{Result: r, Reason: reason})
+		}
+	}
+	return
+}
+
+// Diff splits the violations into the ones introduced by head, whose
+// dependencies are not in base, and the ones already existing in base. The
+// licenses of base are not resolved, as its modules are not installed.
+func Diff(violations []Violation, base []dependency.Dependency) (introduced, existing []Violation) {
+	type key struct {
+		Ecosystem, Name, Version string
+	}
+	inBase := make(map[key]bool, len(base))
+	for _, d := range base {
+		inBase[key{d.Ecosystem, d.Name, d.Version}] = true
+	}
+	for _, v := range violations {
+		if inBase[key{v.Ecosystem, v.Name, v.Version}] {
+			existing = append(existing, v)
+		} else {
+			introduced = append(introduced, v)
+		}
+	}
+	return
+}
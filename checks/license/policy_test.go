@@ -0,0 +1,64 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+
+	conf := util.LicenseConfig{Deny: []string{"GPL-*", "AGPL-*"}}
+	for expr, allowed := range map[string]bool{
+		"MIT":                                  true,
+		"GPL-3.0":                              false,
+		"GPL-2.0+":                             false,
+		"MIT OR GPL-3.0":                       true,
+		"(MIT OR GPL-3.0)":                     true,
+		"MIT AND GPL-3.0":                      false,
+		"(MIT OR Apache-2.0) AND AGPL-3.0":     false,
+		"(MIT) OR (GPL-3.0)":                   true,
+		"GPL-2.0 WITH Classpath-exception-2.0": false,
+		"LGPL-2.1-only or GPL-3.0-only":        true,
+	} {
+		ok, _ := Evaluate(expr, conf)
+		assert.Equal(allowed, ok, expr)
+	}
+	ok, reason := Evaluate("GPL-2.0 OR AGPL-3.0", conf)
+	assert.False(ok)
+	assert.Equal("GPL-2.0 is denied, AGPL-3.0 is denied", reason)
+
+	conf = util.LicenseConfig{Allow: []string{"MIT", "BSD-*"}}
+	ok, reason = Evaluate("MIT AND ISC", conf)
+	assert.False(ok)
+	assert.Equal("ISC is not allowed", reason)
+}
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	results := []Result{
+		{Dependency: dependency.Dependency{Ecosystem: dependency.Go, Name: "a", Version: "v1.0.0"}, License: "MIT"},
+		{Dependency: dependency.Dependency{Ecosystem: dependency.Go, Name: "b", Version: "v1.0.0"}, License: "GPL-3.0"},
+		{Dependency: dependency.Dependency{Ecosystem: dependency.Npm, Name: "c", Version: "1.0.0"}, License: "AGPL-3.0"},
+		{Dependency: dependency.Dependency{Ecosystem: dependency.Npm, Name: "d", Version: "1.0.0"}},
+	}
+	violations, unknown := Check(results, util.LicenseConfig{Deny: []string{"GPL-*", "AGPL-*"}})
+	assert.Equal([]Violation{
+		{Result: results[1], Reason: "GPL-3.0 is denied"},
+		{Result: results[2], Reason: "AGPL-3.0 is denied"},
+	}, violations)
+	assert.Equal([]Result{results[3]}, unknown)
+	assert.Equal("|b|v1.0.0|GPL-3.0|GPL-3.0 is denied|\n", violations[0].MDTableRow())
+	assert.Equal("|d|1.0.0|unknown||\n", Violation{Result: results[3]}.MDTableRow())
+
+	introduced, existing := Diff(violations, []dependency.Dependency{
+		{Ecosystem: dependency.Go, Name: "b", Version: "v1.0.0", Direct: true},
+		{Ecosystem: dependency.Npm, Name: "c", Version: "0.9.0"},
+	})
+	assert.Equal([]Violation{violations[1]}, introduced)
+	assert.Equal([]Violation{violations[0]}, existing)
+}
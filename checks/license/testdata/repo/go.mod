@@ -0,0 +1,9 @@
+module example.com/app
+
+go 1.13
+
+require (
+	github.com/a/mit v1.0.0
+	github.com/b/gpl v0.1.0
+	github.com/c/unknown v2.0.0
+)
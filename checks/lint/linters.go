@@ -204,8 +204,9 @@ func CPPLint(ctx context.Context, ref common.GithubRef, filePath string, cwd str
 		return nil, err
 	}
 	words = append(words, "--quiet", filePath)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 
 	var output bytes.Buffer
 	cmd.Stderr = &output
@@ -292,9 +293,10 @@ func OCLint(ctx context.Context, ref common.GithubRef, filePath string, cwd stri
 
 	var stderr bytes.Buffer
 	// The provided context is used to kill the process (by calling os.Process.Kill)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Stderr = &stderr
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	out, _ := cmd.Output()
 
 	common.LogAccess.Debugf("OCLint Output:\n%s", out)
@@ -348,8 +350,9 @@ func Ktlint(ctx context.Context, ref common.GithubRef, filepath, cwd string) ([]
 	defer cancel()
 
 	// The provided context is used to kill the process (by calling os.Process.Kill)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	out, err := cmd.Output()
 	if err != nil {
 		if ee, ok := err.(*exec.ExitError); ok {
@@ -392,9 +395,10 @@ func PHPLint(ctx context.Context, ref common.GithubRef, fileName, cwd string) ([
 		return nil, stderr.String(), err
 	}
 	words = append(words, "-f", "json", fileName)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Stderr = &stderr
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, stderr.String(), err
@@ -429,8 +433,9 @@ func ESLint(ctx context.Context, ref common.GithubRef, fileName, cwd, eslintrc s
 	} else {
 		words = append(words, "-f", "json", fileName)
 	}
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	cmd.Stderr = &stderr
 	out, err := cmd.Output()
 	if err != nil {
@@ -467,8 +472,9 @@ func TSLint(ctx context.Context, ref common.GithubRef, fileName, tsConfigFile, c
 		words = append(words, "-p", tsConfigFile)
 	}
 	words = append(words, "--format", "json", fileName)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	cmd.Stderr = &stderr
 	out, err := cmd.Output()
 	if err != nil {
@@ -519,8 +525,9 @@ func SCSSLint(ctx context.Context, ref common.GithubRef, fileName, cwd string) (
 		return nil, stderr.String(), err
 	}
 	words = append(words, "--format=JSON", fileName)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	cmd.Stderr = &stderr
 	out, err := cmd.Output()
 	if err != nil {
@@ -599,9 +606,10 @@ func GolangCILint(ctx context.Context, ref common.GithubRef, cwd string) (*Golan
 	defer cancel()
 
 	var stderr bytes.Buffer
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Stderr = &stderr
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	out, _ := cmd.Output()
 
 	common.LogAccess.Debugf("GolangCILint Output:\n%s", out)
@@ -758,8 +766,9 @@ func remark(ctx context.Context, ref common.GithubRef, fileName string, cwd stri
 		return nil, nil, err
 	}
 	words = append(words, "--quiet", "--report", "json", fileName)
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, nil, err
@@ -884,8 +893,9 @@ func APIDoc(ctx context.Context, ref common.GithubRef, cwd string) (string, erro
 	if err != nil {
 		return "parseAPIDocCommands error\n", err
 	}
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	output, err := cmd.CombinedOutput()
 	return string(output) + "\n", err
 }
@@ -979,8 +989,9 @@ func AndroidLint(ctx context.Context, ref common.GithubRef, cwd string) (*Issues
 	}
 	if doCheckstyle {
 		outputs.WriteString("checkstyle:\n")
-		cmd := exec.CommandContext(ctx, checkstyleWords[0], checkstyleWords[1:]...)
+		cmd := util.CommandContext(ctx, checkstyleWords[0], checkstyleWords[1:]...)
 		cmd.Dir = cwd
+		cmd.Env = util.Environ(cwd, ref)
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			outputs.WriteString(err.Error() + "\n")
@@ -1040,8 +1051,9 @@ func AndroidLint(ctx context.Context, ref common.GithubRef, cwd string) (*Issues
 	}
 
 	outputs.WriteString("lint:\n")
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		outputs.WriteString(err.Error() + "\n")
@@ -1125,8 +1137,9 @@ func ClangLint(ctx context.Context, ref common.GithubRef, cwd string, filePath s
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd := util.CommandContext(ctx, words[0], words[1:]...)
 	cmd.Dir = cwd
+	cmd.Env = util.Environ(cwd, ref)
 
 	out, err := cmd.Output()
 	if err != nil {
@@ -0,0 +1,139 @@
+package outdated
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// Package is a direct dependency behind its latest version
+type Package struct {
+	dependency.Requirement
+	// Manifest is the manifest declaring the dependency, relative to the repo
+	Manifest string
+	// Current is the locked version, or the lowest allowed by the constraint
+	Current string
+	Latest  string
+	// MajorsBehind is the number of the major versions behind, and
+	// MinorsBehind is the one of the minor versions in the same major
+	MajorsBehind int64
+	MinorsBehind int64
+}
+
+// MDTitle returns the title of the markdown table used to report packages
+func (Package) MDTitle() string {
+	return "|name|current|latest|behind|manifest|\n|---|---|---|---|---|\n"
+}
+
+// MDTableRow formats Package p into a row of the markdown table
+func (p Package) MDTableRow() string {
+	behind := strconv.FormatInt(p.MinorsBehind, 10) + " minor"
+	if p.MajorsBehind > 0 {
+		behind = strconv.FormatInt(p.MajorsBehind, 10) + " major"
+	}
+	return "|" + p.Name + "|" + p.Current + "|" + p.Latest + "|" + behind + "|" + p.Manifest + "|\n"
+}
+
+// lockfiles maps the manifests to their lockfiles, package.json is parsed by
+// its lockfile
+var lockfiles = map[string]string{
+	"package.json":  "package.json",
+	"composer.json": "composer.lock",
+}
+
+// lockedVersions returns the versions of the direct dependencies locked for
+// the manifest, the lockfile is ignored if it is missing or invalid
+func lockedVersions(manifest string) map[string]string {
+	name, ok := lockfiles[filepath.Base(manifest)]
+	if !ok {
+		return nil
+	}
+	deps, err := dependency.Parse(filepath.Join(filepath.Dir(manifest), name))
+	if err != nil {
+		return nil
+	}
+	versions := make(map[string]string)
+	for _, d := range deps {
+		if d.Direct {
+			versions[d.Name] = d.Version
+		}
+	}
+	return versions
+}
+
+// Behind returns the major and minor versions current is behind latest, the
+// minor versions are only counted in the same major version
+func Behind(current, latest string) (majors, minors int64, ok bool) {
+	curMajor, curMinor, ok := dependency.MajorMinor(current)
+	if !ok {
+		return 0, 0, false
+	}
+	latMajor, latMinor, ok := dependency.MajorMinor(latest)
+	if !ok || dependency.CompareVersions(latest, current) <= 0 {
+		return 0, 0, false
+	}
+	if latMajor > curMajor {
+		return latMajor - curMajor, 0, true
+	}
+	if latMajor == curMajor && latMinor > curMinor {
+		return 0, latMinor - curMinor, true
+	}
+	return 0, 0, false
+}
+
+// Check returns the direct dependencies declared in the manifests of the repo
+// which are behind their latest versions in the registry more than allowed
+func Check(repoPath string, registry Registry, conf util.OutdatedConfig) ([]Package, error) {
+	manifests, err := dependency.Manifests(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var packages []Package
+	for _, manifest := range manifests {
+		rel, _ := filepath.Rel(repoPath, manifest)
+		rel = filepath.ToSlash(rel)
+		reqs, err := dependency.ParseManifest(manifest)
+		if err != nil {
+			return nil, &dependency.ParseError{File: rel, Err: err}
+		}
+		locked := lockedVersions(manifest)
+		for _, req := range reqs {
+			current := locked[req.Name]
+			if current == "" {
+				current = req.Version()
+			}
+			if current == "" {
+				continue
+			}
+			latest, err := registry.Latest(req.Ecosystem, req.Name)
+			if err != nil {
+				return nil, err
+			}
+			majors, minors, ok := Behind(current, latest)
+			if !ok || !conf.Exceeds(majors, minors) {
+				continue
+			}
+			packages = append(packages, Package{
+				Requirement:  req,
+				Manifest:     rel,
+				Current:      current,
+				Latest:       latest,
+				MajorsBehind: majors,
+				MinorsBehind: minors,
+			})
+		}
+	}
+	return packages, nil
+}
+
+// Touched reports whether any of the changed files is a manifest or lockfile
+func Touched(files []string) bool {
+	for _, file := range files {
+		if dependency.IsManifest(file) || dependency.Supported(file) {
+			return true
+		}
+	}
+	return false
+}
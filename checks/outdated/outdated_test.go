@@ -0,0 +1,88 @@
+package outdated
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/config"
+	"github.com/tengattack/unified-ci/util"
+)
+
+var testRegistry = Registry{config.SectionRegistry{
+	GoProxyPath:   "testdata/registry/goproxy",
+	NpmPath:       "testdata/registry/npm",
+	PackagistPath: "testdata/registry/packagist",
+}}
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	packages, err := Check("testdata/repo", testRegistry, util.OutdatedConfig{Major: 1, Minor: 2})
+	require.NoError(err)
+	assert.Equal([]Package{
+		{
+			Requirement: dependency.Requirement{Ecosystem: dependency.Packagist, Name: "monolog/monolog", Constraint: "^1.0"},
+			Manifest:    "composer.json", Current: "1.25.1", Latest: "3.0.0", MajorsBehind: 2,
+		},
+		{
+			Requirement: dependency.Requirement{Ecosystem: dependency.Go, Name: "github.com/Foo/Baz", Constraint: "v0.1.0"},
+			Manifest:    "go.mod", Current: "v0.1.0", Latest: "v0.5.0", MinorsBehind: 4,
+		},
+		{
+			Requirement: dependency.Requirement{Ecosystem: dependency.Go, Name: "github.com/foo/bar", Constraint: "v1.2.0"},
+			Manifest:    "go.mod", Current: "v1.2.0", Latest: "v3.1.0", MajorsBehind: 2,
+		},
+		{
+			Requirement: dependency.Requirement{Ecosystem: dependency.Npm, Name: "@scope/pkg", Constraint: "~2.0.0"},
+			Manifest:    "web/package.json", Current: "2.0.0", Latest: "2.5.0", MinorsBehind: 5,
+		},
+		{
+			Requirement: dependency.Requirement{Ecosystem: dependency.Npm, Name: "react", Constraint: "^16.0.0"},
+			Manifest:    "web/package.json", Current: "16.14.0", Latest: "18.2.0", MajorsBehind: 2,
+		},
+	}, packages)
+
+	assert.Equal("|name|current|latest|behind|manifest|\n|---|---|---|---|---|\n"+
+		"|monolog/monolog|1.25.1|3.0.0|2 major|composer.json|\n"+
+		"|github.com/Foo/Baz|v0.1.0|v0.5.0|4 minor|go.mod|\n",
+		Package{}.MDTitle()+packages[0].MDTableRow()+packages[1].MDTableRow())
+
+	// the mirrors are not configured
+	packages, err = Check("testdata/repo", Registry{}, util.OutdatedConfig{Major: 1, Minor: 2})
+	require.NoError(err)
+	assert.Empty(packages)
+}
+
+func TestBehind(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, c := range []struct {
+		current, latest string
+		majors, minors  int64
+		ok              bool
+	}{
+		{"1.2.0", "3.1.0", 2, 0, true},
+		{"v1.2.0", "v1.5.3", 0, 3, true},
+		{"1.2.0", "1.2.9", 0, 0, false},
+		{"2.0.0", "1.9.0", 0, 0, false},
+		{"1.2.0", "", 0, 0, false},
+		{"v1.0.0", "v2.0.0+incompatible", 1, 0, true},
+	} {
+		majors, minors, ok := Behind(c.current, c.latest)
+		assert.Equal(c.ok, ok, c.current+" "+c.latest)
+		assert.Equal(c.majors, majors, c.current+" "+c.latest)
+		assert.Equal(c.minors, minors, c.current+" "+c.latest)
+	}
+}
+
+func TestTouched(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(Touched([]string{"main.go", "web/package.json"}))
+	assert.True(Touched([]string{"composer.lock"}))
+	assert.True(Touched([]string{"go.sum"}))
+	assert.False(Touched([]string{"main.go", "README.md"}))
+}
@@ -0,0 +1,186 @@
+package outdated
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/config"
+)
+
+// Registry looks up the latest versions in the local registry mirrors
+type Registry struct {
+	config.SectionRegistry
+}
+
+// Latest returns the latest stable version of the package, it is empty if
+// the package or the mirror of its ecosystem is not found
+func (r Registry) Latest(ecosystem, name string) (string, error) {
+	if !validName(name) {
+		// the names from the manifests are joined into the mirror paths
+		return "", nil
+	}
+	switch ecosystem {
+	case dependency.Go:
+		if r.GoProxyPath == "" {
+			return "", nil
+		}
+		return r.latestGo(name)
+	case dependency.Npm:
+		if r.NpmPath == "" {
+			return "", nil
+		}
+		return r.latestNpm(name)
+	case dependency.Packagist:
+		if r.PackagistPath == "" {
+			return "", nil
+		}
+		return r.latestPackagist(name)
+	}
+	return "", nil
+}
+
+// validName reports whether the package name stays under the mirror root as
+// a path, that is it has no empty, "." or ".." segments
+func validName(name string) bool {
+	if name == "" || strings.ContainsAny(name, "\\\x00") {
+		return false
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// latest returns the highest stable version
+func latest(versions []string) string {
+	var v string
+	for _, ver := range versions {
+		if !dependency.ValidVersion(ver) || dependency.Prerelease(ver) {
+			continue
+		}
+		if v == "" || dependency.CompareVersions(ver, v) > 0 {
+			v = ver
+		}
+	}
+	return v
+}
+
+// escapeModulePath escapes the upper case letters in the module path as the
+// module proxy protocol, e.g. "github.com/!azure/azure-sdk-for-go"
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, c := range path {
+		if 'A' <= c && c <= 'Z' {
+			b.WriteByte('!')
+			c += 'a' - 'A'
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// goModuleVersions reads the versions of the module in the proxy list file
+func (r Registry) goModuleVersions(path string) ([]string, error) {
+	f, err := os.Open(filepath.Join(r.GoProxyPath, filepath.FromSlash(escapeModulePath(path)), "@v", "list"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var versions []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) > 0 {
+			versions = append(versions, fields[0])
+		}
+	}
+	return versions, s.Err()
+}
+
+var goMajorSuffixRegexp = regexp.MustCompile(`/v([2-9]|[1-9][0-9]+)$`)
+
+// latestGo returns the latest version of the Go module, including the ones
+// of the newer major versions at the module paths with the "/vN" suffixes
+func (r Registry) latestGo(name string) (string, error) {
+	base, major := name, 1
+	if m := goMajorSuffixRegexp.FindStringSubmatch(name); m != nil {
+		base = strings.TrimSuffix(name, m[0])
+		major, _ = strconv.Atoi(m[1])
+	}
+	versions, err := r.goModuleVersions(name)
+	if err != nil {
+		return "", err
+	}
+	for {
+		major++
+		vs, err := r.goModuleVersions(fmt.Sprintf("%s/v%d", base, major))
+		if err != nil {
+			return "", err
+		}
+		if len(vs) == 0 {
+			break
+		}
+		versions = append(versions, vs...)
+	}
+	return latest(versions), nil
+}
+
+// latestNpm reads the packument of the package, the "latest" dist-tag is
+// preferred
+func (r Registry) latestNpm(name string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(r.NpmPath, filepath.FromSlash(name), "package.json"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var packument struct {
+		DistTags map[string]string          `json:"dist-tags"`
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err = json.Unmarshal(content, &packument); err != nil {
+		return "", fmt.Errorf("invalid metadata of %s: %v", name, err)
+	}
+	if v := packument.DistTags["latest"]; v != "" {
+		return v, nil
+	}
+	versions := make([]string, 0, len(packument.Versions))
+	for v := range packument.Versions {
+		versions = append(versions, v)
+	}
+	return latest(versions), nil
+}
+
+// latestPackagist reads the composer v2 metadata of the package
+func (r Registry) latestPackagist(name string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(r.PackagistPath, "p2", filepath.FromSlash(name)+".json"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var metadata struct {
+		Packages map[string][]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if err = json.Unmarshal(content, &metadata); err != nil {
+		return "", fmt.Errorf("invalid metadata of %s: %v", name, err)
+	}
+	var versions []string
+	for _, p := range metadata.Packages[name] {
+		versions = append(versions, p.Version)
+	}
+	return latest(versions), nil
+}
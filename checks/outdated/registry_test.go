@@ -0,0 +1,58 @@
+package outdated
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/checks/dependency"
+)
+
+func TestLatest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	for _, c := range []struct {
+		ecosystem, name, latest string
+	}{
+		{dependency.Go, "github.com/foo/bar", "v3.1.0"},
+		{dependency.Go, "github.com/foo/bar/v2", "v3.1.0"},
+		{dependency.Go, "github.com/Foo/Baz", "v0.5.0"},
+		{dependency.Go, "github.com/not/found", ""},
+		{dependency.Npm, "left-pad", "1.3.0"},
+		{dependency.Npm, "react", "18.2.0"},
+		{dependency.Npm, "@scope/pkg", "2.5.0"},
+		{dependency.Npm, "not-found", ""},
+		{dependency.Packagist, "monolog/monolog", "3.0.0"},
+		{dependency.Packagist, "psr/log", ""},
+		{dependency.PyPI, "requests", ""},
+		// the names outside of the mirrors
+		{dependency.Npm, "../npm/react", ""},
+		{dependency.Npm, "/react", ""},
+		{dependency.Packagist, "../packagist/p2/monolog/monolog", ""},
+		{dependency.Go, "github.com/foo/../foo/bar", ""},
+	} {
+		latest, err := testRegistry.Latest(c.ecosystem, c.name)
+		require.NoError(err)
+		assert.Equal(c.latest, latest, c.name)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(validName("@scope/pkg"))
+	assert.True(validName("github.com/foo/bar.v2"))
+	assert.False(validName(""))
+	assert.False(validName(".."))
+	assert.False(validName("foo/../../bar"))
+	assert.False(validName("foo//bar"))
+	assert.False(validName(`..\bar`))
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("github.com/!azure/azure-sdk-for-go", escapeModulePath("github.com/Azure/azure-sdk-for-go"))
+	assert.Equal("golang.org/x/text", escapeModulePath("golang.org/x/text"))
+}
@@ -0,0 +1,10 @@
+module example.com/app
+
+go 1.13
+
+require (
+	github.com/Foo/Baz v0.1.0
+	github.com/foo/bar v1.2.0
+	example.com/up v1.1.0
+	golang.org/x/text v0.3.2 // indirect
+)
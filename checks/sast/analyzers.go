@@ -0,0 +1,235 @@
+package sast
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+var sqlInjectionAnalyzer = &analysis.Analyzer{
+	Name:     "sqlinjection",
+	Doc:      "reports the SQL queries built by string concatenation or formatting",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runSQLInjection,
+}
+
+var commandInjectionAnalyzer = &analysis.Analyzer{
+	Name:     "commandinjection",
+	Doc:      "reports the subprocesses launched with variable commands",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runCommandInjection,
+}
+
+var insecureTLSAnalyzer = &analysis.Analyzer{
+	Name:     "insecuretls",
+	Doc:      "reports the TLS configs skipping the verification or allowing the versions before TLS 1.2",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runInsecureTLS,
+}
+
+var weakCipherAnalyzer = &analysis.Analyzer{
+	Name:     "weakcipher",
+	Doc:      "reports the uses of the broken ciphers DES and RC4",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runWeakCrypto(map[string]bool{"crypto/des": true, "crypto/rc4": true}, "is a broken cipher, use crypto/aes"),
+}
+
+var weakHashAnalyzer = &analysis.Analyzer{
+	Name:     "weakhash",
+	Doc:      "reports the uses of the weak hashes MD5 and SHA-1",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runWeakCrypto(map[string]bool{"crypto/md5": true, "crypto/sha1": true}, "is a weak hash, use crypto/sha256"),
+}
+
+// callee returns the function called, nil for the conversions and builtins
+func callee(info *types.Info, call *ast.CallExpr) *types.Func {
+	fn, _ := typeutil.Callee(info, call).(*types.Func)
+	return fn
+}
+
+// isConstant reports whether the expression is a constant, the expressions
+// without type information are considered constant not to report them
+func isConstant(info *types.Info, e ast.Expr) bool {
+	tv, ok := info.Types[e]
+	return !ok || tv.Value != nil
+}
+
+// constantString returns the value of the string constant
+func constantString(info *types.Info, e ast.Expr) (string, bool) {
+	tv, ok := info.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// sqlQueryArgs maps the methods of database/sql to the index of the query
+var sqlQueryArgs = make(map[string]int)
+
+func init() {
+	for _, recv := range []string{"DB", "Tx", "Conn"} {
+		for _, method := range []string{"Query", "QueryRow", "Exec", "Prepare"} {
+			sqlQueryArgs["(*database/sql."+recv+")."+method] = 0
+			sqlQueryArgs["(*database/sql."+recv+")."+method+"Context"] = 1
+		}
+	}
+}
+
+// builtString reports whether the string is built by concatenation or
+// formatting at the call
+func builtString(info *types.Info, e ast.Expr) bool {
+	if isConstant(info, e) {
+		return false
+	}
+	switch e := astutil.Unparen(e).(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD
+	case *ast.CallExpr:
+		if fn := callee(info, e); fn != nil {
+			name := fn.FullName()
+			return name == "fmt.Sprintf" || name == "strings.Join" || name == "strings.Replace"
+		}
+	}
+	return false
+}
+
+func runSQLInjection(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn := callee(pass.TypesInfo, call)
+		if fn == nil {
+			return
+		}
+		i, ok := sqlQueryArgs[fn.FullName()]
+		if !ok || i >= len(call.Args) {
+			return
+		}
+		if builtString(pass.TypesInfo, call.Args[i]) {
+			pass.Reportf(call.Args[i].Pos(), "SQL query built from strings, pass the values as the query arguments")
+		}
+	})
+	return nil, nil
+}
+
+// shells run the commands in their "-c" arguments
+var shells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "/bin/sh": true, "/bin/bash": true, "/usr/bin/env": true,
+}
+
+func runCommandInjection(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn := callee(pass.TypesInfo, call)
+		if fn == nil {
+			return
+		}
+		var args []ast.Expr
+		switch fn.FullName() {
+		case "os/exec.Command":
+			args = call.Args
+		case "os/exec.CommandContext":
+			if len(call.Args) > 0 {
+				args = call.Args[1:]
+			}
+		default:
+			return
+		}
+		if len(args) == 0 || call.Ellipsis.IsValid() && len(args) == 1 {
+			return
+		}
+		name, ok := constantString(pass.TypesInfo, args[0])
+		if !ok {
+			if !isConstant(pass.TypesInfo, args[0]) {
+				pass.Reportf(args[0].Pos(), "subprocess launched with a variable command")
+			}
+			return
+		}
+		if !shells[name] {
+			return
+		}
+		for i, arg := range args[1:] {
+			if s, _ := constantString(pass.TypesInfo, arg); s == "-c" && i+2 < len(args) {
+				script := args[i+2]
+				if !isConstant(pass.TypesInfo, script) {
+					pass.Reportf(script.Pos(), "shell command built from variables")
+				}
+				return
+			}
+		}
+	})
+	return nil, nil
+}
+
+// isTLSConfig reports whether t is crypto/tls.Config
+func isTLSConfig(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "crypto/tls" && obj.Name() == "Config"
+}
+
+func runInsecureTLS(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CompositeLit)(nil)}, func(n ast.Node) {
+		lit := n.(*ast.CompositeLit)
+		if !isTLSConfig(pass.TypesInfo.TypeOf(lit)) {
+			return
+		}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			tv := pass.TypesInfo.Types[kv.Value]
+			if tv.Value == nil {
+				continue
+			}
+			switch key.Name {
+			case "InsecureSkipVerify":
+				if tv.Value.Kind() == constant.Bool && constant.BoolVal(tv.Value) {
+					pass.Reportf(kv.Pos(), "TLS InsecureSkipVerify set true")
+				}
+			case "MinVersion":
+				// tls.VersionTLS12
+				if v, ok := constant.Uint64Val(tv.Value); ok && v < 0x0303 {
+					pass.Reportf(kv.Pos(), "TLS MinVersion before TLS 1.2")
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+// runWeakCrypto reports the calls to the functions of the packages
+func runWeakCrypto(pkgs map[string]bool, message string) func(pass *analysis.Pass) (interface{}, error) {
+	return func(pass *analysis.Pass) (interface{}, error) {
+		inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+		inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+			call := n.(*ast.CallExpr)
+			fn := callee(pass.TypesInfo, call)
+			if fn == nil || fn.Pkg() == nil || !pkgs[fn.Pkg().Path()] {
+				return
+			}
+			if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+				return
+			}
+			pass.Reportf(call.Pos(), "%s %s", fn.Pkg().Path(), message)
+		})
+		return nil, nil
+	}
+}
@@ -0,0 +1,183 @@
+package sast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path/filepath"
+	"runtime"
+
+	"github.com/tengattack/unified-ci/util"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// goRule is an analyzer with the weakness it detects
+type goRule struct {
+	Analyzer *analysis.Analyzer
+	CWE      string
+	Severity string
+}
+
+// goRules are the built-in rules for Go, identified by the analyzer names
+var goRules = []goRule{
+	{sqlInjectionAnalyzer, "CWE-89", High},
+	{commandInjectionAnalyzer, "CWE-78", Medium},
+	{insecureTLSAnalyzer, "CWE-295", High},
+	{weakCipherAnalyzer, "CWE-327", High},
+	{weakHashAnalyzer, "CWE-328", Medium},
+}
+
+// CheckGo runs the analyzers in-process on the packages of the Go modules in
+// the repo. The packages are type-checked from the source, the type errors
+// like the missing dependencies are tolerated, and the modules failing to
+// load are logged and skipped.
+func CheckGo(repoPath string, conf util.SecurityConfig, log io.Writer) ([]Finding, error) {
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var rules []goRule
+	for _, r := range goRules {
+		if !conf.RuleDisabled(r.Analyzer.Name) {
+			rules = append(rules, r)
+		}
+	}
+	mods, err := sourceFiles(repoPath, ".mod")
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, mod := range mods {
+		if filepath.Base(mod) != "go.mod" || len(rules) == 0 {
+			continue
+		}
+		f, err := checkGoModule(repoPath, filepath.Dir(mod), rules)
+		if err != nil {
+			rel, _ := filepath.Rel(repoPath, mod)
+			_, _ = io.WriteString(log, fmt.Sprintf("Skipped %s: %v\n", filepath.ToSlash(rel), err))
+			continue
+		}
+		findings = append(findings, f...)
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+func checkGoModule(repoPath, dir string, rules []goRule) ([]Finding, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Dir:  dir,
+		Mode: packages.NeedName | packages.NeedFiles,
+	}, "./...")
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Sizes:    types.SizesFor("gc", runtime.GOARCH),
+		Error:    func(error) {},
+	}
+	var findings []Finding
+	for _, pkg := range pkgs {
+		var files []*ast.File
+		for _, name := range pkg.GoFiles {
+			f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+			if err != nil {
+				continue
+			}
+			files = append(files, f)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		info := &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:     make(map[ast.Node]*types.Scope),
+		}
+		typesPkg, _ := conf.Check(pkg.PkgPath, fset, files, info)
+		pass := analysis.Pass{
+			Fset:       fset,
+			Files:      files,
+			OtherFiles: pkg.OtherFiles,
+			Pkg:        typesPkg,
+			TypesInfo:  info,
+			TypesSizes: conf.Sizes,
+		}
+		for _, r := range rules {
+			diagnostics, err := runAnalyzer(pass, r.Analyzer)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range diagnostics {
+				pos := fset.Position(d.Pos)
+				rel, err := filepath.Rel(repoPath, pos.Filename)
+				if err != nil {
+					continue
+				}
+				findings = append(findings, Finding{
+					Path:     filepath.ToSlash(rel),
+					Line:     pos.Line,
+					Column:   pos.Column,
+					RuleID:   r.Analyzer.Name,
+					CWE:      r.CWE,
+					Severity: r.Severity,
+					Message:  d.Message,
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// runAnalyzer runs the analyzer and the ones it requires on the package of
+// the pass, only the diagnostics of the analyzer itself are returned. The
+// facts are not supported.
+func runAnalyzer(pass analysis.Pass, a *analysis.Analyzer) ([]analysis.Diagnostic, error) {
+	results := make(map[*analysis.Analyzer]interface{})
+	var diagnostics []analysis.Diagnostic
+	var run func(a *analysis.Analyzer) (interface{}, error)
+	run = func(a *analysis.Analyzer) (interface{}, error) {
+		if result, ok := results[a]; ok {
+			return result, nil
+		}
+		resultOf := make(map[*analysis.Analyzer]interface{})
+		for _, req := range a.Requires {
+			result, err := run(req)
+			if err != nil {
+				return nil, err
+			}
+			resultOf[req] = result
+		}
+		p := pass
+		p.Analyzer = a
+		p.ResultOf = resultOf
+		p.Report = func(d analysis.Diagnostic) {}
+		if a == pass.Analyzer {
+			p.Report = func(d analysis.Diagnostic) {
+				diagnostics = append(diagnostics, d)
+			}
+		}
+		p.ImportObjectFact = func(types.Object, analysis.Fact) bool { return false }
+		p.ExportObjectFact = func(types.Object, analysis.Fact) {}
+		p.ImportPackageFact = func(*types.Package, analysis.Fact) bool { return false }
+		p.ExportPackageFact = func(analysis.Fact) {}
+		result, err := a.Run(&p)
+		if err != nil {
+			return nil, err
+		}
+		results[a] = result
+		return result, nil
+	}
+	pass.Analyzer = a
+	_, err := run(a)
+	return diagnostics, err
+}
@@ -0,0 +1,38 @@
+package sast
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestCheckGo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	findings, err := CheckGo("testdata/go", util.SecurityConfig{}, ioutil.Discard)
+	require.NoError(err)
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, f.String())
+	}
+	assert.Equal([]string{
+		"db/db.go:18:30 high CWE-89 [sqlinjection] SQL query built from strings, pass the values as the query arguments",
+		"db/db.go:19:18 high CWE-89 [sqlinjection] SQL query built from strings, pass the values as the query arguments",
+		"db/db.go:27:37 medium CWE-78 [commandinjection] shell command built from variables",
+		"db/db.go:30:22 medium CWE-78 [commandinjection] subprocess launched with a variable command",
+		"db/db.go:36:3 high CWE-295 [insecuretls] TLS InsecureSkipVerify set true",
+		"db/db.go:37:3 high CWE-295 [insecuretls] TLS MinVersion before TLS 1.2",
+		"db/db.go:43:9 medium CWE-328 [weakhash] crypto/md5 is a weak hash, use crypto/sha256",
+		"db/db.go:48:12 high CWE-327 [weakcipher] crypto/des is a broken cipher, use crypto/aes",
+	}, lines)
+
+	findings, err = CheckGo("testdata/go", util.SecurityConfig{
+		DisabledRules: []string{"sqlinjection", "commandinjection", "insecuretls", "weakcipher", "weakhash"},
+	}, ioutil.Discard)
+	require.NoError(err)
+	assert.Empty(findings)
+}
@@ -0,0 +1,181 @@
+package sast
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tengattack/unified-ci/util"
+)
+
+// phpInput matches the superglobals of the user input
+const phpInput = `\$_(?:GET|POST|REQUEST|COOKIE|FILES|SERVER)\b`
+
+// phpRule flags the lines matched by the regexp
+type phpRule struct {
+	ID       string
+	CWE      string
+	Severity string
+	Regexp   *regexp.Regexp
+	Message  string
+}
+
+// phpRules are the built-in taint patterns, the user input passed directly to
+// the dangerous functions
+var phpRules = []phpRule{
+	{
+		ID:       "php-eval",
+		CWE:      "CWE-95",
+		Severity: High,
+		Regexp:   regexp.MustCompile(`\b(?:eval|assert|create_function)\s*\([^;]*` + phpInput),
+		Message:  "code evaluated from the user input",
+	},
+	{
+		ID:       "php-command-injection",
+		CWE:      "CWE-78",
+		Severity: High,
+		Regexp:   regexp.MustCompile(`(?:\b(?:exec|system|passthru|shell_exec|popen|proc_open|pcntl_exec)\s*\(|` + "`" + `)[^;]*` + phpInput),
+		Message:  "command executed with the user input",
+	},
+	{
+		ID:       "php-sql-injection",
+		CWE:      "CWE-89",
+		Severity: High,
+		Regexp: regexp.MustCompile(`(?i)(?:\b(?:mysqli?_query|pg_query|sqlite_query)\s*\(|->\s*(?:query|exec|prepare)\s*\(|` +
+			`["']\s*(?:SELECT|INSERT|UPDATE|DELETE)\b)[^;]*` + phpInput),
+		Message: "SQL query built from the user input, use the prepared statements",
+	},
+	{
+		ID:       "php-file-inclusion",
+		CWE:      "CWE-98",
+		Severity: High,
+		Regexp:   regexp.MustCompile(`\b(?:include|include_once|require|require_once)\b[^;]*` + phpInput),
+		Message:  "file included by the user input",
+	},
+	{
+		ID:       "php-xss",
+		CWE:      "CWE-79",
+		Severity: Medium,
+		Regexp:   regexp.MustCompile(`\b(?:echo|print)\b[^;]*` + phpInput),
+		Message:  "user input printed without escaping, use htmlspecialchars",
+	},
+	{
+		ID:       "php-unserialize",
+		CWE:      "CWE-502",
+		Severity: High,
+		Regexp:   regexp.MustCompile(`\bunserialize\s*\([^;]*` + phpInput),
+		Message:  "user input unserialized, use json_decode",
+	},
+	{
+		ID:       "php-weak-hash",
+		CWE:      "CWE-328",
+		Severity: Low,
+		Regexp:   regexp.MustCompile(`\b(?:md5|sha1)\s*\(`),
+		Message:  "weak hash, use password_hash for the passwords",
+	},
+}
+
+// enabledPHPRules returns the enabled built-in rules followed by the custom
+// ones in the config
+func enabledPHPRules(conf util.SecurityConfig) ([]phpRule, error) {
+	var rules []phpRule
+	for _, r := range phpRules {
+		if !conf.RuleDisabled(r.ID) {
+			rules = append(rules, r)
+		}
+	}
+	for _, r := range conf.PHPRules {
+		re, err := regexp.Compile(r.Regexp)
+		if err != nil {
+			return nil, err
+		}
+		severity := r.Severity
+		if severity == "" {
+			severity = Medium
+		}
+		message := r.Message
+		if message == "" {
+			message = r.ID
+		}
+		rules = append(rules, phpRule{
+			ID:       r.ID,
+			CWE:      r.CWE,
+			Severity: severity,
+			Regexp:   re,
+			Message:  message,
+		})
+	}
+	return rules, nil
+}
+
+// isPHPComment reports whether the trimmed line is a comment
+func isPHPComment(line string) bool {
+	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") ||
+		strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*")
+}
+
+// CheckPHP matches the rules against the lines of the PHP files in the repo,
+// each line is reported once by the first rule matching it
+func CheckPHP(repoPath string, conf util.SecurityConfig) ([]Finding, error) {
+	rules, err := enabledPHPRules(conf)
+	if err != nil {
+		return nil, err
+	}
+	files, err := sourceFiles(repoPath, ".php")
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, file := range files {
+		rel, err := filepath.Rel(repoPath, file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := checkPHPFile(file, filepath.ToSlash(rel), rules)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, f...)
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+func checkPHPFile(file, path string, rules []phpRule) ([]Finding, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var findings []Finding
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if isPHPComment(strings.TrimSpace(line)) {
+			continue
+		}
+		for _, r := range rules {
+			loc := r.Regexp.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			findings = append(findings, Finding{
+				Path:     path,
+				Line:     lineNum,
+				Column:   loc[0] + 1,
+				RuleID:   r.ID,
+				CWE:      r.CWE,
+				Severity: r.Severity,
+				Message:  r.Message,
+			})
+			break
+		}
+	}
+	return findings, scanner.Err()
+}
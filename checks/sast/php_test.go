@@ -0,0 +1,38 @@
+package sast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestCheckPHP(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	findings, err := CheckPHP("testdata/php", util.SecurityConfig{})
+	require.NoError(err)
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, f.String())
+	}
+	assert.Equal([]string{
+		"index.php:4:9 high CWE-89 [php-sql-injection] SQL query built from the user input, use the prepared statements",
+		"index.php:5:1 high CWE-78 [php-command-injection] command executed with the user input",
+		"index.php:6:1 medium CWE-79 [php-xss] user input printed without escaping, use htmlspecialchars",
+		"index.php:8:1 high CWE-98 [php-file-inclusion] file included by the user input",
+		"index.php:9:10 low CWE-328 [php-weak-hash] weak hash, use password_hash for the passwords",
+	}, lines)
+
+	findings, err = CheckPHP("testdata/php", util.SecurityConfig{
+		DisabledRules: []string{"php-sql-injection", "php-command-injection", "php-xss", "php-file-inclusion", "php-weak-hash"},
+		PHPRules: []util.SecurityRule{
+			{ID: "php-intval", CWE: "CWE-20", Regexp: `\bintval\(`},
+		},
+	})
+	require.NoError(err)
+	require.Len(findings, 1)
+	assert.Equal("index.php:3:7 medium CWE-20 [php-intval] php-intval", findings[0].String())
+}
@@ -0,0 +1,143 @@
+package sast
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// Severities of the findings
+const (
+	High   = "high"
+	Medium = "medium"
+	Low    = "low"
+)
+
+// AnnotationLevel maps the severity to the level of the annotation
+func AnnotationLevel(severity string) string {
+	switch severity {
+	case High:
+		return "failure"
+	case Medium:
+		return "warning"
+	}
+	return "notice"
+}
+
+// Finding is a security weakness in the source
+type Finding struct {
+	// Path is relative to the repo
+	Path     string
+	Line     int
+	Column   int
+	RuleID   string
+	CWE      string
+	Severity string
+	Message  string
+}
+
+// String formats the finding as a log line
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d %s %s [%s] %s", f.Path, f.Line, f.Column, f.Severity, f.CWE, f.RuleID, f.Message)
+}
+
+// Annotation returns the annotation of the finding at the level of its severity
+func (f Finding) Annotation() *github.CheckRunAnnotation {
+	path, line := f.Path, f.Line
+	level := AnnotationLevel(f.Severity)
+	message := fmt.Sprintf("`%s` %s: %s", f.RuleID, f.CWE, f.Message)
+	return &github.CheckRunAnnotation{
+		Path:            &path,
+		StartLine:       &line,
+		EndLine:         &line,
+		AnnotationLevel: &level,
+		Message:         &message,
+	}
+}
+
+// sortFindings sorts the findings by the positions
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// FilterDiff returns the findings in the hunks of diffs like the lint
+// results, the other findings are already in base
+func FilterDiff(findings []Finding, diffs []*diff.FileDiff) []Finding {
+	hunks := make(map[string][]*diff.Hunk)
+	for _, d := range diffs {
+		path, _ := util.GetTrimmedNewName(d)
+		hunks[path] = append(hunks[path], d.Hunks...)
+	}
+	var filtered []Finding
+	for _, f := range findings {
+		for _, hunk := range hunks[f.Path] {
+			if hunk.NewLines > 0 && f.Line >= int(hunk.NewStartLine) &&
+				f.Line <= int(hunk.NewStartLine+hunk.NewLines-1) {
+				filtered = append(filtered, f)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// skipDirs are not analyzed
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"testdata":     true,
+}
+
+// sourceFiles returns the files with the extension in the repo, the
+// dependencies and the hidden directories are skipped
+func sourceFiles(repoPath, ext string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != repoPath && (skipDirs[name] || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(name) == ext {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Check runs the Go and PHP rules on the repo
+func Check(repoPath string, conf util.SecurityConfig, log io.Writer) ([]Finding, error) {
+	findings, err := CheckGo(repoPath, conf, log)
+	if err != nil {
+		return nil, fmt.Errorf("go: %v", err)
+	}
+	php, err := CheckPHP(repoPath, conf)
+	if err != nil {
+		return nil, fmt.Errorf("php: %v", err)
+	}
+	findings = append(findings, php...)
+	sortFindings(findings)
+	return findings, nil
+}
@@ -0,0 +1,86 @@
+package sast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestAnnotationLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("failure", AnnotationLevel(High))
+	assert.Equal("warning", AnnotationLevel(Medium))
+	assert.Equal("notice", AnnotationLevel(Low))
+	assert.Equal("notice", AnnotationLevel(""))
+}
+
+func TestFindingAnnotation(t *testing.T) {
+	assert := assert.New(t)
+
+	f := Finding{Path: "db/db.go", Line: 17, Column: 30, RuleID: "sqlinjection", CWE: "CWE-89", Severity: High, Message: "SQL query"}
+	assert.Equal("db/db.go:17:30 high CWE-89 [sqlinjection] SQL query", f.String())
+	a := f.Annotation()
+	assert.Equal("db/db.go", a.GetPath())
+	assert.Equal(17, a.GetStartLine())
+	assert.Equal("failure", a.GetAnnotationLevel())
+	assert.Equal("`sqlinjection` CWE-89: SQL query", a.GetMessage())
+}
+
+func TestFilterDiff(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	diffs, err := diff.ParseMultiFileDiff([]byte(`diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -10,3 +10,4 @@ func a() {
+ 	a := 1
++	b := 2
+ 	c := 3
+ 	d := 4
+`))
+	require.NoError(err)
+	findings := []Finding{
+		{Path: "a.go", Line: 9},
+		{Path: "a.go", Line: 11},
+		{Path: "a.go", Line: 14},
+		{Path: "b.go", Line: 11},
+	}
+	assert.Equal([]Finding{{Path: "a.go", Line: 11}}, FilterDiff(findings, diffs))
+}
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	log := new(strings.Builder)
+	findings, err := Check("testdata", util.SecurityConfig{
+		DisabledRules: []string{"weakhash", "php-weak-hash"},
+	}, log)
+	require.NoError(err)
+	// the module failing to load is skipped
+	assert.Contains(log.String(), "Skipped broken/go.mod: ")
+	var rules []string
+	for _, f := range findings {
+		rules = append(rules, f.Path+" "+f.RuleID)
+	}
+	assert.Equal([]string{
+		"go/db/db.go sqlinjection",
+		"go/db/db.go sqlinjection",
+		"go/db/db.go commandinjection",
+		"go/db/db.go commandinjection",
+		"go/db/db.go insecuretls",
+		"go/db/db.go insecuretls",
+		"go/db/db.go weakcipher",
+		"php/index.php php-sql-injection",
+		"php/index.php php-command-injection",
+		"php/index.php php-xss",
+		"php/index.php php-file-inclusion",
+	}, rules)
+}
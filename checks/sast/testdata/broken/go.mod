@@ -0,0 +1,5 @@
+module example.com/broken
+
+go 1.13
+
+require (
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"os/exec"
+)
+
+// Find queries the user
+func Find(ctx context.Context, db *sql.DB, name string) (*sql.Rows, error) {
+	if _, err := db.Query("SELECT * FROM users WHERE name = ?", name); err != nil {
+		return nil, err
+	}
+	_ = db.QueryRowContext(ctx, fmt.Sprintf("SELECT * FROM users WHERE name = '%s'", name))
+	return db.Query("SELECT * FROM users WHERE name = '" + name + "'")
+}
+
+// Run runs the command
+func Run(name, arg string) error {
+	if err := exec.Command("git", "status").Run(); err != nil {
+		return err
+	}
+	if err := exec.Command("sh", "-c", "ls "+arg).Run(); err != nil {
+		return err
+	}
+	return exec.Command(name).Run()
+}
+
+// Client returns the TLS config
+func Client() *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+	}
+}
+
+// Sum hashes the data
+func Sum(data []byte) [16]byte {
+	return md5.Sum(data)
+}
+
+// Cipher returns the block cipher
+func Cipher(key []byte) error {
+	_, err := des.NewCipher(key)
+	return err
+}
@@ -0,0 +1,9 @@
+<?php
+// eval($_GET['code']);
+$id = intval($_GET['id']);
+$rows = mysqli_query($conn, "SELECT * FROM users WHERE id = " . $_GET['id']);
+system("ls " . $_POST['dir']);
+echo $_GET['name'];
+echo htmlspecialchars($name);
+include $_REQUEST['page'] . '.php';
+$token = md5($name);
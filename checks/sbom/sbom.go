@@ -0,0 +1,272 @@
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/tengattack/unified-ci/checks/dependency"
+)
+
+// Formats of the SBOM documents
+const (
+	CycloneDX = "cyclonedx"
+	SPDX      = "spdx"
+)
+
+// file names of the SBOM documents in the artifacts directory
+var fileNames = map[string]string{
+	CycloneDX: "sbom.cdx.json",
+	SPDX:      "sbom.spdx.json",
+}
+
+// ErrUnknownFormat is returned for the formats not supported
+var ErrUnknownFormat = errors.New("unknown sbom format")
+
+// FileName returns the file name of the SBOM document in the format
+func FileName(format string) (string, error) {
+	name, ok := fileNames[format]
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+	return name, nil
+}
+
+// purlTypes maps the ecosystems to the package URL types,
+// see https://github.com/package-url/purl-spec
+var purlTypes = map[string]string{
+	dependency.Go:        "golang",
+	dependency.Npm:       "npm",
+	dependency.Packagist: "composer",
+	dependency.Maven:     "maven",
+	dependency.PyPI:      "pypi",
+	dependency.Cargo:     "cargo",
+	dependency.RubyGems:  "gem",
+}
+
+// PURL returns the package URL of the dependency
+func PURL(d dependency.Dependency) string {
+	typ, ok := purlTypes[d.Ecosystem]
+	if !ok {
+		typ = strings.ToLower(d.Ecosystem)
+	}
+	name := d.Name
+	if d.Ecosystem == dependency.Maven {
+		// the namespace of maven is the group
+		name = strings.Replace(name, ":", "/", 1)
+	}
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		// "@" separates the version, so it is escaped in the npm scopes
+		parts[i] = strings.ReplaceAll(url.PathEscape(p), "@", "%40")
+	}
+	return "pkg:" + typ + "/" + strings.Join(parts, "/") + "@" + url.PathEscape(d.Version)
+}
+
+// Document is the subject of the SBOM
+type Document struct {
+	// Name is the name of the repo like "owner/repo"
+	Name string
+	// Version is the commit SHA
+	Version      string
+	Dependencies []dependency.Dependency
+	// Created is the time the document is created at
+	Created time.Time
+	// ToolName and ToolVersion are of the generator
+	ToolName    string
+	ToolVersion string
+}
+
+// serialNumber identifies the document of the commit, the same one for
+// the same commit
+func (doc *Document) serialNumber() uuid.UUID {
+	return uuid.NewV5(uuid.NamespaceURL, doc.Name+"@"+doc.Version)
+}
+
+func (doc *Document) rootRef() string {
+	return "pkg:generic/" + url.PathEscape(doc.Name) + "@" + url.PathEscape(doc.Version)
+}
+
+type cdxComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// MarshalCycloneDX encodes the document in the CycloneDX 1.4 JSON format,
+// only the direct dependencies of the repo are known in the graph.
+func (doc *Document) MarshalCycloneDX() ([]byte, error) {
+	type tool struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	var bom struct {
+		BOMFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Version      int    `json:"version"`
+		Metadata     struct {
+			Timestamp string       `json:"timestamp"`
+			Tools     []tool       `json:"tools"`
+			Component cdxComponent `json:"component"`
+		} `json:"metadata"`
+		Components   []cdxComponent  `json:"components"`
+		Dependencies []cdxDependency `json:"dependencies"`
+	}
+	bom.BOMFormat = "CycloneDX"
+	bom.SpecVersion = "1.4"
+	bom.SerialNumber = "urn:uuid:" + doc.serialNumber().String()
+	bom.Version = 1
+	bom.Metadata.Timestamp = doc.Created.UTC().Format(time.RFC3339)
+	bom.Metadata.Tools = []tool{{Name: doc.ToolName, Version: doc.ToolVersion}}
+	root := cdxComponent{
+		Type:    "application",
+		BOMRef:  doc.rootRef(),
+		Name:    doc.Name,
+		Version: doc.Version,
+	}
+	bom.Metadata.Component = root
+
+	bom.Components = make([]cdxComponent, 0, len(doc.Dependencies))
+	direct := cdxDependency{Ref: root.BOMRef, DependsOn: []string{}}
+	for _, d := range doc.Dependencies {
+		purl := PURL(d)
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:    "library",
+			BOMRef:  purl,
+			Name:    d.Name,
+			Version: d.Version,
+			PURL:    purl,
+		})
+		if d.Direct {
+			direct.DependsOn = append(direct.DependsOn, purl)
+		}
+	}
+	bom.Dependencies = []cdxDependency{direct}
+	return json.MarshalIndent(&bom, "", "  ")
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// MarshalSPDX encodes the document in the SPDX 2.3 JSON format, the repo
+// package depends on all the dependencies, and the transitive ones are noted
+// in their comments as the graph is unknown.
+func (doc *Document) MarshalSPDX() ([]byte, error) {
+	var spdx struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DataLicense       string `json:"dataLicense"`
+		SPDXID            string `json:"SPDXID"`
+		Name              string `json:"name"`
+		DocumentNamespace string `json:"documentNamespace"`
+		CreationInfo      struct {
+			Created  string   `json:"created"`
+			Creators []string `json:"creators"`
+		} `json:"creationInfo"`
+		Packages      []spdxPackage      `json:"packages"`
+		Relationships []spdxRelationship `json:"relationships"`
+	}
+	spdx.SPDXVersion = "SPDX-2.3"
+	spdx.DataLicense = "CC0-1.0"
+	spdx.SPDXID = "SPDXRef-DOCUMENT"
+	spdx.Name = doc.Name + "@" + doc.Version
+	spdx.DocumentNamespace = "https://spdx.org/spdxdocs/" + url.PathEscape(doc.Name) + "-" + doc.serialNumber().String()
+	spdx.CreationInfo.Created = doc.Created.UTC().Format(time.RFC3339)
+	spdx.CreationInfo.Creators = []string{"Tool: " + doc.ToolName + "-" + doc.ToolVersion}
+
+	const rootID = "SPDXRef-Repository"
+	spdx.Packages = []spdxPackage{{
+		SPDXID:           rootID,
+		Name:             doc.Name,
+		VersionInfo:      doc.Version,
+		DownloadLocation: "NOASSERTION",
+	}}
+	spdx.Relationships = []spdxRelationship{{
+		SPDXElementID:      spdx.SPDXID,
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: rootID,
+	}}
+	for i, d := range doc.Dependencies {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		spdx.Packages = append(spdx.Packages, spdxPackage{
+			SPDXID:           id,
+			Name:             d.Name,
+			VersionInfo:      d.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  PURL(d),
+			}},
+		})
+		if !d.Direct {
+			spdx.Packages[len(spdx.Packages)-1].Comment = "transitive dependency"
+		}
+		spdx.Relationships = append(spdx.Relationships, spdxRelationship{
+			SPDXElementID:      rootID,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: id,
+		})
+	}
+	return json.MarshalIndent(&spdx, "", "  ")
+}
+
+// Save writes the documents in all the formats into dir, it returns the
+// paths of the written files.
+func (doc *Document) Save(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, format := range []string{CycloneDX, SPDX} {
+		var content []byte
+		var err error
+		if format == CycloneDX {
+			content, err = doc.MarshalCycloneDX()
+		} else {
+			content, err = doc.MarshalSPDX()
+		}
+		if err != nil {
+			return files, err
+		}
+		path := filepath.Join(dir, fileNames[format])
+		if err = ioutil.WriteFile(path, content, 0644); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,138 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/checks/dependency"
+)
+
+var testDocument = Document{
+	Name:    "owner/repo",
+	Version: "0123456789abcdef",
+	Dependencies: []dependency.Dependency{
+		{Ecosystem: dependency.Go, Name: "github.com/gin-gonic/gin", Version: "v1.5.0", Direct: true},
+		{Ecosystem: dependency.Npm, Name: "@babel/core", Version: "7.12.3", Direct: false},
+	},
+	Created:     time.Date(2021, 6, 30, 12, 0, 0, 0, time.UTC),
+	ToolName:    "unified-ci",
+	ToolVersion: "0.3.0",
+}
+
+func TestPURL(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("pkg:golang/github.com/gin-gonic/gin@v1.5.0",
+		PURL(dependency.Dependency{Ecosystem: dependency.Go, Name: "github.com/gin-gonic/gin", Version: "v1.5.0"}))
+	assert.Equal("pkg:npm/%40babel/core@7.12.3",
+		PURL(dependency.Dependency{Ecosystem: dependency.Npm, Name: "@babel/core", Version: "7.12.3"}))
+	assert.Equal("pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+		PURL(dependency.Dependency{Ecosystem: dependency.Maven, Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"}))
+	assert.Equal("pkg:composer/monolog/monolog@1.25.1",
+		PURL(dependency.Dependency{Ecosystem: dependency.Packagist, Name: "monolog/monolog", Version: "1.25.1"}))
+	assert.Equal("pkg:cargo/rand@0.8.5",
+		PURL(dependency.Dependency{Ecosystem: dependency.Cargo, Name: "rand", Version: "0.8.5"}))
+}
+
+func TestMarshalCycloneDX(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	content, err := testDocument.MarshalCycloneDX()
+	require.NoError(err)
+	var bom struct {
+		BOMFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Metadata     struct {
+			Timestamp string `json:"timestamp"`
+			Component struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"component"`
+		} `json:"metadata"`
+		Components []struct {
+			Type string `json:"type"`
+			PURL string `json:"purl"`
+		} `json:"components"`
+		Dependencies []struct {
+			DependsOn []string `json:"dependsOn"`
+		} `json:"dependencies"`
+	}
+	require.NoError(json.Unmarshal(content, &bom))
+	assert.Equal("CycloneDX", bom.BOMFormat)
+	assert.Equal("1.4", bom.SpecVersion)
+	assert.Regexp(`^urn:uuid:[0-9a-f-]{36}$`, bom.SerialNumber)
+	assert.Equal("2021-06-30T12:00:00Z", bom.Metadata.Timestamp)
+	assert.Equal("owner/repo", bom.Metadata.Component.Name)
+	assert.Equal("0123456789abcdef", bom.Metadata.Component.Version)
+	require.Len(bom.Components, 2)
+	assert.Equal("library", bom.Components[0].Type)
+	assert.Equal("pkg:npm/%40babel/core@7.12.3", bom.Components[1].PURL)
+	require.Len(bom.Dependencies, 1)
+	assert.Equal([]string{"pkg:golang/github.com/gin-gonic/gin@v1.5.0"}, bom.Dependencies[0].DependsOn)
+
+	// the serial number is the same for the same commit
+	again, err := testDocument.MarshalCycloneDX()
+	require.NoError(err)
+	assert.Equal(content, again)
+}
+
+func TestMarshalSPDX(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	content, err := testDocument.MarshalSPDX()
+	require.NoError(err)
+	var spdx struct {
+		SPDXVersion  string `json:"spdxVersion"`
+		Name         string `json:"name"`
+		CreationInfo struct {
+			Creators []string `json:"creators"`
+		} `json:"creationInfo"`
+		Packages      []spdxPackage      `json:"packages"`
+		Relationships []spdxRelationship `json:"relationships"`
+	}
+	require.NoError(json.Unmarshal(content, &spdx))
+	assert.Equal("SPDX-2.3", spdx.SPDXVersion)
+	assert.Equal("owner/repo@0123456789abcdef", spdx.Name)
+	assert.Equal([]string{"Tool: unified-ci-0.3.0"}, spdx.CreationInfo.Creators)
+	require.Len(spdx.Packages, 3)
+	assert.Equal("SPDXRef-Repository", spdx.Packages[0].SPDXID)
+	assert.Equal("pkg:golang/github.com/gin-gonic/gin@v1.5.0", spdx.Packages[1].ExternalRefs[0].ReferenceLocator)
+	assert.Empty(spdx.Packages[1].Comment)
+	assert.Equal("transitive dependency", spdx.Packages[2].Comment)
+	assert.Equal([]spdxRelationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Repository"},
+		{"SPDXRef-Repository", "DEPENDS_ON", "SPDXRef-Package-1"},
+		{"SPDXRef-Repository", "DEPENDS_ON", "SPDXRef-Package-2"},
+	}, spdx.Relationships)
+}
+
+func TestSave(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files, err := testDocument.Save(filepath.Join(dir, "sha.artifacts"))
+	require.NoError(err)
+	assert.Equal([]string{
+		filepath.Join(dir, "sha.artifacts", "sbom.cdx.json"),
+		filepath.Join(dir, "sha.artifacts", "sbom.spdx.json"),
+	}, files)
+
+	name, err := FileName(SPDX)
+	assert.NoError(err)
+	assert.Equal("sbom.spdx.json", name)
+	_, err = FileName("swid")
+	assert.Equal(ErrUnknownFormat, err)
+}
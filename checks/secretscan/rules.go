@@ -0,0 +1,115 @@
+package secretscan
+
+import (
+	"math"
+	"regexp"
+
+	"github.com/tengattack/unified-ci/util"
+)
+
+// Rule detects the secrets by the regexp, the secret is the group named
+// "secret" if any, otherwise the whole match
+type Rule struct {
+	ID          string
+	Description string
+	Regexp      *regexp.Regexp
+	// Entropy is the minimum Shannon entropy of the secret, 0 not to check
+	Entropy float64
+}
+
+// builtinRules are in the order of precedence, a secret matched by multiple
+// rules is reported by the first one
+var builtinRules = []Rule{
+	{
+		ID:          "aws-access-key-id",
+		Description: "AWS access key ID",
+		Regexp:      regexp.MustCompile(`\b(?P<secret>(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16})\b`),
+	},
+	{
+		ID:          "aws-secret-access-key",
+		Description: "AWS secret access key",
+		Regexp:      regexp.MustCompile(`(?i)aws[\w.-]*secret[\w.-]*["']?\s*(?::=|=>|=|:)\s*["']?(?P<secret>[A-Za-z0-9/+]{40})\b`),
+		Entropy:     3.5,
+	},
+	{
+		ID:          "private-key",
+		Description: "private key",
+		Regexp:      regexp.MustCompile(`-----BEGIN[ A-Z0-9_-]*PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		ID:          "github-token",
+		Description: "GitHub token",
+		Regexp:      regexp.MustCompile(`\b(?P<secret>gh[pousr]_[A-Za-z0-9]{36,251}|github_pat_[A-Za-z0-9_]{82})\b`),
+	},
+	{
+		ID:          "generic-secret",
+		Description: "high entropy secret",
+		Regexp: regexp.MustCompile(`(?i)[\w.-]*(?:secret|token|passw(?:or)?d|pwd|api[_-]?key|access[_-]?key|credential)[\w.-]*["']?` +
+			`\s*(?::=|=>|=|:)\s*["'](?P<secret>[^"'\s${}<>]{16,})["']`),
+		Entropy: 3.5,
+	},
+}
+
+// Rules returns the built-in rules followed by the custom ones in the config
+func Rules(conf util.SecretScanConfig) ([]Rule, error) {
+	rules := append([]Rule(nil), builtinRules...)
+	for _, r := range conf.Rules {
+		re, err := regexp.Compile(r.Regexp)
+		if err != nil {
+			return nil, err
+		}
+		description := r.Description
+		if description == "" {
+			description = r.ID
+		}
+		rules = append(rules, Rule{
+			ID:          r.ID,
+			Description: description,
+			Regexp:      re,
+			Entropy:     r.Entropy,
+		})
+	}
+	return rules, nil
+}
+
+// secrets returns the secrets matched by the rule in the line
+func (r Rule) secrets(line string) []string {
+	group := 0
+	for i, name := range r.Regexp.SubexpNames() {
+		if name == "secret" {
+			group = i
+			break
+		}
+	}
+	var secrets []string
+	for _, m := range r.Regexp.FindAllStringSubmatch(line, -1) {
+		secret := m[0]
+		if group > 0 {
+			secret = m[group]
+		}
+		if secret == "" || (r.Entropy > 0 && Entropy(secret) < r.Entropy) {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+// Entropy returns the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var e float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		e -= p * math.Log2(p)
+	}
+	return e
+}
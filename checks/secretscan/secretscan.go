@@ -0,0 +1,140 @@
+package secretscan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// allowMarker in an added line skips the secrets in the line
+const allowMarker = "unified-ci:allow"
+
+// Finding is a secret in an added line
+type Finding struct {
+	Path string
+	Line int
+	Rule Rule
+	// Secret is the matched value, never to be logged but Redacted
+	Secret string
+}
+
+// Redacted returns the secret with only the first characters kept
+func (f Finding) Redacted() string {
+	return Redact(f.Secret)
+}
+
+// String formats the finding with the secret redacted
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d %s %s", f.Path, f.Line, f.Rule.ID, f.Redacted())
+}
+
+// Annotation returns the failure annotation of the finding
+func (f Finding) Annotation() *github.CheckRunAnnotation {
+	path, line := f.Path, f.Line
+	level := "failure"
+	message := fmt.Sprintf("`%s` %s: %s", f.Rule.ID, f.Rule.Description, f.Redacted())
+	return &github.CheckRunAnnotation{
+		Path:            &path,
+		StartLine:       &line,
+		EndLine:         &line,
+		AnnotationLevel: &level,
+		Message:         &message,
+	}
+}
+
+// Redact keeps at most 4 leading characters of the secret, up to a quarter of
+// it, and masks the rest
+func Redact(secret string) string {
+	n := len(secret) / 4
+	if n > 4 {
+		n = 4
+	}
+	return secret[:n] + "****"
+}
+
+// Scanner detects the secrets by the rules
+type Scanner struct {
+	rules        []Rule
+	allowPaths   []string
+	allowRegexps []*regexp.Regexp
+}
+
+// NewScanner creates the scanner of the built-in and custom rules
+func NewScanner(conf util.SecretScanConfig) (*Scanner, error) {
+	rules, err := Rules(conf)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scanner{rules: rules, allowPaths: conf.Allowlist.Paths}
+	for _, p := range conf.Allowlist.Regexps {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		s.allowRegexps = append(s.allowRegexps, re)
+	}
+	return s, nil
+}
+
+func (s *Scanner) allowed(secret string) bool {
+	for _, re := range s.allowRegexps {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanLine returns the findings in the line, each secret is reported once
+// by the first rule matching it
+func (s *Scanner) ScanLine(path string, lineNum int, line string) []Finding {
+	if strings.Contains(line, allowMarker) {
+		return nil
+	}
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, r := range s.rules {
+		for _, secret := range r.secrets(line) {
+			if seen[secret] || s.allowed(secret) {
+				continue
+			}
+			seen[secret] = true
+			findings = append(findings, Finding{Path: path, Line: lineNum, Rule: r, Secret: secret})
+		}
+	}
+	return findings
+}
+
+// Scan returns the findings in the lines added by diffs, the deleted files
+// and the allowed paths are skipped
+func (s *Scanner) Scan(diffs []*diff.FileDiff) []Finding {
+	var findings []Finding
+	for _, d := range diffs {
+		if util.Unquote(d.NewName) == "/dev/null" {
+			continue
+		}
+		path, _ := util.GetTrimmedNewName(d)
+		if util.MatchAny(s.allowPaths, path) {
+			continue
+		}
+		for _, hunk := range d.Hunks {
+			lineNum := int(hunk.NewStartLine)
+			lines := strings.Split(strings.TrimSuffix(string(hunk.Body), "\n"), "\n")
+			for _, line := range lines {
+				switch {
+				case line == "" || line[0] == ' ':
+					// the trailing spaces of the empty context lines may be trimmed
+					lineNum++
+				case line[0] == '+':
+					findings = append(findings, s.ScanLine(path, lineNum, line[1:])...)
+					lineNum++
+				}
+			}
+		}
+	}
+	return findings
+}
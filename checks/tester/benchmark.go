@@ -0,0 +1,86 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tengattack/unified-ci/checks/benchmark"
+	"github.com/tengattack/unified-ci/common"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// runBenchmarks runs the benchmark cmds in repoPath and parses their output
+func runBenchmarks(ctx context.Context, ref common.GithubRef, config util.BenchmarksConfig,
+	repoPath string, log io.Writer) (benchmark.Set, error) {
+	dir, err := config.Dir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	sandbox, err := util.NewSandbox(config.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply resource limits: %v", err)
+	}
+	defer sandbox.Close()
+	parser := newTestShellParser(repoPath, dir, ref, config.Env)
+	if len(ref.Secrets) > 0 {
+		masked := util.NewMaskWriter(log, ref.Secrets)
+		defer masked.Flush()
+		log = masked
+	}
+	env := testEnviron(repoPath, ref, config.TestsConfig)
+
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	out := new(strings.Builder)
+	for i, cmd := range config.Commands() {
+		if cmd == "" {
+			continue
+		}
+		_, _ = io.WriteString(log, cmd+"\n")
+		err = carry(ctx, parser, config.CommandShell(i), dir, env, sandbox, cmd, io.MultiWriter(log, out))
+		if err != nil {
+			if v := sandbox.Violation(err); v != "" {
+				return nil, fmt.Errorf("%s (%v)", v, err)
+			}
+			return nil, err
+		}
+	}
+	return benchmark.Parse(strings.NewReader(out.String()))
+}
+
+// CompareBenchmarks runs the benchmarks on head in repoPath and then on base in
+// a separate worktree, one after another so that they do not disturb each other.
+func CompareBenchmarks(ctx context.Context, ref common.GithubRef, baseSHA string, config util.BenchmarksConfig,
+	repoPath string, cache util.CacheConfig, log io.Writer) ([]benchmark.Comparison, error) {
+	_, _ = io.WriteString(log, "Benchmarking head\n")
+	head, err := runBenchmarks(ctx, ref, config, repoPath, log)
+	if err != nil {
+		return nil, fmt.Errorf("head benchmarks failed: %v", err)
+	}
+
+	_, _ = io.WriteString(log, "\nBenchmarking base\n")
+	worktreePath, err := AddBaseWorktree(ref, repoPath, baseSHA, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worktree of base: %v", err)
+	}
+	defer RemoveBaseWorktree(ref, repoPath, worktreePath, log)
+	if cache.Enabled() {
+		restoreBaseCache(ref, worktreePath, cache, log)
+	}
+	baseRef := ref
+	baseRef.Sha = baseSHA
+	if baseRef.CheckType == common.CheckTypePRHead {
+		baseRef.CheckType = common.CheckTypePRBase
+	}
+	base, err := runBenchmarks(ctx, baseRef, config, worktreePath, log)
+	if err != nil {
+		return nil, fmt.Errorf("base benchmarks failed: %v", err)
+	}
+	_, _ = io.WriteString(log, "\n")
+	return benchmark.Compare(base, head, config.Threshold), nil
+}
@@ -0,0 +1,73 @@
+package tester
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/common"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestCompareBenchmarks(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+
+	git := func(args ...string) string {
+		var out strings.Builder
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=user@test.com"}, args...)...)
+		cmd.Dir = repoPath
+		cmd.Stdout = &out
+		require.NoError(cmd.Run())
+		return strings.TrimSpace(out.String())
+	}
+	bench := func(ns ...string) string {
+		script := ""
+		for _, v := range ns {
+			script += "echo 'BenchmarkRun-8 1000 " + v + " ns/op'\n"
+		}
+		return script
+	}
+	git("init")
+	require.NoError(ioutil.WriteFile(path.Join(repoPath, "bench.sh"), []byte(bench("100", "101", "99", "100", "102")), 0644))
+	git("add", "bench.sh")
+	git("commit", "-m", "base")
+	baseSHA := git("rev-parse", "HEAD")
+	require.NoError(ioutil.WriteFile(path.Join(repoPath, "bench.sh"), []byte(bench("150", "151", "149", "150", "152")), 0644))
+	git("commit", "-am", "head")
+
+	ref := common.GithubRef{
+		Owner:     "owner",
+		RepoName:  "repo",
+		Sha:       git("rev-parse", "HEAD"),
+		CheckType: common.CheckTypePRHead,
+	}
+	config := util.BenchmarksConfig{Threshold: 20}
+	config.Cmds = []string{"sh bench.sh"}
+	comparisons, err := CompareBenchmarks(context.TODO(), ref, baseSHA, config, repoPath, util.CacheConfig{}, ioutil.Discard)
+	require.NoError(err)
+	require.Len(comparisons, 1)
+	assert.Equal("Run-8", comparisons[0].Name)
+	assert.InDelta(50, comparisons[0].Delta, 1)
+	assert.True(comparisons[0].Regression)
+
+	config.Threshold = 60
+	comparisons, err = CompareBenchmarks(context.TODO(), ref, baseSHA, config, repoPath, util.CacheConfig{}, ioutil.Discard)
+	require.NoError(err)
+	require.Len(comparisons, 1)
+	assert.False(comparisons[0].Regression)
+
+	config.Cmds = []string{"false"}
+	_, err = CompareBenchmarks(context.TODO(), ref, baseSHA, config, repoPath, util.CacheConfig{}, ioutil.Discard)
+	assert.Error(err)
+}
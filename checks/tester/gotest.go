@@ -5,7 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -27,23 +28,69 @@ func (t *testNotPass) Error() (s string) {
 	return
 }
 
-func carry(ctx context.Context, p *shellwords.Parser, repo, cmd string, log io.Writer) error {
-	words, err := p.Parse(cmd)
-	if err != nil {
-		return err
-	}
-	if len(words) < 1 {
-		return errors.New("invalid command")
+// carry runs the cmd, which is parsed by p or run by the shell if any
+func carry(ctx context.Context, p *shellwords.Parser, shell, dir string, env []string, sandbox *util.Sandbox, cmd string, log io.Writer) error {
+	var words []string
+	if shell != "" {
+		name, args := util.ShellCommand(shell, cmd)
+		words = append([]string{name}, args...)
+	} else {
+		var err error
+		words, err = p.Parse(cmd)
+		if err != nil {
+			return err
+		}
+		if len(words) < 1 {
+			return errors.New("invalid command")
+		}
 	}
 
-	cmds := exec.CommandContext(ctx, words[0], words[1:]...)
-	cmds.Dir = repo
+	cmds := util.CommandContext(ctx, words[0], words[1:]...)
+	cmds.Dir = dir
+	cmds.Sandbox = sandbox
+	if len(env) > 0 {
+		cmds.Env = append(os.Environ(), env...)
+	}
 	cmds.Stdout = log
 	cmds.Stderr = log
 
 	return cmds.Run()
 }
 
+// newTestShellParser returns a shell parser which also expands the test's env
+// and the secrets
+func newTestShellParser(repoPath, dir string, ref common.GithubRef, env map[string]string) *shellwords.Parser {
+	parser := util.NewShellParser(repoPath, ref)
+	parser.Dir = dir
+	getenv := parser.Getenv
+	parser.Getenv = func(key string) string {
+		if util.IsCIVariable(key) {
+			return getenv(key)
+		}
+		if v, ok := env[key]; ok {
+			return v
+		}
+		if v, ok := ref.Secrets[key]; ok {
+			return v
+		}
+		return getenv(key)
+	}
+	return parser
+}
+
+// testEnviron returns the extra environment variables of the test with the CI
+// variables and the secrets of the repo. The env of the test and the secrets
+// cannot override the CI variables, as the last duplicate is taken.
+func testEnviron(repoPath string, ref common.GithubRef, testConfig util.TestsConfig) []string {
+	env := util.CIEnviron(repoPath, ref)
+	for _, kv := range append(util.SecretsEnviron(ref.Secrets), testConfig.Environ()...) {
+		if !util.IsCIVariable(strings.SplitN(kv, "=", 2)[0]) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
 func parseCoverage(pattern, output string) (string, float64, error) {
 	coverage := "unknown"
 	r, err := regexp.Compile(pattern)
@@ -62,27 +109,78 @@ func parseCoverage(pattern, output string) (string, float64, error) {
 	return coverage, pct, nil
 }
 
-func testAndSaveCoverage(ctx context.Context, ref common.GithubRef, testName string, cmds []string, coveragePattern string, deltaCoveragePattern string,
+func testAndSaveCoverage(ctx context.Context, ref common.GithubRef, testName string, testConfig util.TestsConfig,
 	repoPath string, gpull *github.PullRequest, breakOnFails bool, log io.Writer) (result *Result) {
 	var reportMessage, outputSummary string
-	parser := util.NewShellParser(repoPath, ref)
+	coveragePattern := testConfig.Coverage
+	if len(ref.Secrets) > 0 {
+		masked := util.NewMaskWriter(log, ref.Secrets)
+		defer masked.Flush()
+		log = masked
+	}
+	deltaCoveragePattern := testConfig.DeltaCoverage
 
 	_, _ = io.WriteString(log, fmt.Sprintf("Testing '%s'\n", testName))
 	conclusion := "success"
-	for _, cmd := range cmds {
+
+	dir, err := testConfig.Dir(repoPath)
+	if err != nil {
+		errMsg := err.Error() + "\n"
+		_, _ = io.WriteString(log, errMsg+"\n")
+		return &Result{
+			Conclusion:    "failure",
+			OutputSummary: errMsg,
+		}
+	}
+	sandbox, err := util.NewSandbox(testConfig.Limits)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to apply resource limits: %v\n", err)
+		_, _ = io.WriteString(log, errMsg+"\n")
+		return &Result{
+			Conclusion:    "failure",
+			OutputSummary: errMsg,
+		}
+	}
+	defer sandbox.Close()
+	parser := newTestShellParser(repoPath, dir, ref, testConfig.Env)
+	env := testEnviron(repoPath, ref, testConfig)
+	var violation string
+	output := new(strings.Builder)
+
+	if testConfig.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, testConfig.Timeout)
+		defer cancel()
+	}
+	for i, cmd := range testConfig.Commands() {
 		if cmd != "" {
 			_, _ = io.WriteString(log, cmd+"\n")
 			out := new(strings.Builder)
-			errCmd := carry(ctx, parser, repoPath, cmd, io.MultiWriter(log, out))
-			outputSummary += cmd + "\n" + out.String() + "\n"
+			errCmd := carry(ctx, parser, testConfig.CommandShell(i), dir, env, sandbox, cmd, io.MultiWriter(log, out))
+			outStr := util.MaskSecrets(out.String(), ref.Secrets)
+			outputSummary += cmd + "\n" + outStr + "\n"
+			output.WriteString(outStr)
 			if errCmd != nil {
 				errMsg := errCmd.Error() + "\n"
+				if v := sandbox.Violation(errCmd); v != "" {
+					violation = v
+					errMsg = v + " (" + errCmd.Error() + ")\n"
+				}
+				// the error may quote the command with the secrets
+				errMsg = util.MaskSecrets(errMsg, ref.Secrets)
 				outputSummary += errMsg
 				_, _ = io.WriteString(log, errMsg)
 			}
 
 			if errCmd != nil {
 				conclusion = "failure"
+				if testConfig.Timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+					errMsg := fmt.Sprintf("Test timed out after %s\n", testConfig.Timeout)
+					outputSummary += errMsg
+					_, _ = io.WriteString(log, errMsg)
+					conclusion = "timed_out"
+					break
+				}
 				if breakOnFails {
 					break
 				}
@@ -168,11 +266,56 @@ func testAndSaveCoverage(ctx context.Context, ref common.GithubRef, testName str
 			reportMessage = deltaMessage
 		}
 	}
+	if conclusion != "success" && testConfig.AllowFailure {
+		msg := fmt.Sprintf("Test %s is allowed to fail\n", conclusion)
+		outputSummary += msg
+		_, _ = io.WriteString(log, msg)
+		conclusion = "neutral"
+	}
+	var annotations []*github.CheckRunAnnotation
+	if len(testConfig.Matchers) > 0 && ref.CheckType != common.CheckTypePRBase {
+		annotations = matchProblems(testConfig.Matchers, output.String(), repoPath, dir)
+		if len(annotations) > 0 {
+			_, _ = io.WriteString(log, fmt.Sprintf("%d problem(s) matched\n", len(annotations)))
+		}
+	}
+	var artifactsURL string
+	if len(testConfig.Artifacts) > 0 && ref.CheckType != common.CheckTypePRBase {
+		var msg string
+		artifactsURL, msg = saveArtifacts(ref, testName, testConfig.Artifacts, repoPath)
+		outputSummary += msg
+		_, _ = io.WriteString(log, msg)
+	}
 	_, _ = io.WriteString(log, "\n")
 	result = &Result{
 		Conclusion:    conclusion,
 		ReportMessage: reportMessage,
 		OutputSummary: outputSummary,
+		Violation:     violation,
+		ArtifactsURL:  artifactsURL,
+		Annotations:   annotations,
 	}
 	return
 }
+
+// saveArtifacts archives the test artifacts next to the log, it returns the
+// download url and the message to log
+func saveArtifacts(ref common.GithubRef, testName string, patterns []string, repoPath string) (string, string) {
+	maxSize, err := util.ParseByteSize(common.Conf.Artifacts.MaxSize)
+	if err != nil {
+		return "", fmt.Sprintf("Failed to archive artifacts: invalid max size: %v\n", err)
+	}
+	name := util.ArtifactsName(testName)
+	dest := filepath.Join(util.ArtifactsDir(common.Conf.Core.LogsDir, ref.Owner, ref.RepoName, ref.Sha), name)
+	n, err := util.ArchiveArtifacts(repoPath, patterns, dest, maxSize)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to archive artifacts: %v\n", err)
+		common.LogError.Error(msg)
+		return "", msg
+	}
+	if n == 0 {
+		return "", "No artifacts found\n"
+	}
+	url := util.ArtifactsURL(common.Conf.Artifacts.URI, ref.Owner, ref.RepoName, ref.Sha, name)
+	return url, fmt.Sprintf("Archived %d artifact(s) to %s\n", n, name)
+}
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	shellwords "github.com/mattn/go-shellwords"
 	"github.com/stretchr/testify/assert"
@@ -47,7 +48,7 @@ func TestCoverRegex(t *testing.T) {
 	for _, cmd := range test.Cmds {
 		out := new(strings.Builder)
 		w := io.MultiWriter(log, out)
-		errCmd := carry(context.Background(), parser, repo, cmd, w)
+		errCmd := carry(context.Background(), parser, "", repo, nil, nil, cmd, w)
 		assert.NoError(errCmd)
 		output += ("\n" + out.String())
 	}
@@ -109,7 +110,7 @@ func TestDeltaTest(t *testing.T) {
 	for _, cmd := range test.Cmds {
 		out := new(strings.Builder)
 		w := io.MultiWriter(log, out)
-		errCmd := carry(context.Background(), parser, repo, cmd, w)
+		errCmd := carry(context.Background(), parser, "", repo, nil, nil, cmd, w)
 		assert.NoError(errCmd)
 		output += ("\n" + out.String())
 	}
@@ -121,3 +122,120 @@ func TestDeltaTest(t *testing.T) {
 	assert.Equal("60%", result)
 	assert.Equal(0.6, pct)
 }
+
+func TestTestOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	_, filepath, _, _ := runtime.Caller(0)
+	repo := path.Dir(filepath) + "/../../testdata/go"
+	ref := common.GithubRef{}
+
+	log := new(strings.Builder)
+	result := testAndSaveCoverage(context.Background(), ref, "timeout", util.TestsConfig{
+		Cmds:    []string{"sleep 5"},
+		Timeout: 100 * time.Millisecond,
+	}, repo, nil, false, log)
+	assert.Equal("timed_out", result.Conclusion)
+	assert.Contains(result.OutputSummary, "timed out after 100ms")
+
+	result = testAndSaveCoverage(context.Background(), ref, "env", util.TestsConfig{
+		Cmds: []string{`sh -c 'test "$FOO" = bar'`, "test $FOO = bar"},
+		Env:  map[string]string{"FOO": "bar"},
+	}, repo, nil, false, log)
+	assert.Equal("success", result.Conclusion)
+
+	result = testAndSaveCoverage(context.Background(), ref, "working_dir", util.TestsConfig{
+		Cmds:       []string{"test -d go"},
+		WorkingDir: "..",
+	}, repo, nil, false, log)
+	assert.Equal("failure", result.Conclusion)
+
+	result = testAndSaveCoverage(context.Background(), ref, "working_dir", util.TestsConfig{
+		Cmds:       []string{"test -f sample.go"},
+		WorkingDir: ".",
+	}, repo, nil, false, log)
+	assert.Equal("success", result.Conclusion)
+
+	result = testAndSaveCoverage(context.Background(), common.GithubRef{
+		Sha: "abc",
+		Env: map[string]string{"BAR": "foo"},
+	}, "ci_env", util.TestsConfig{
+		Cmds: []string{`sh -c 'test "$CI" = true -a "$CI_COMMIT_SHA" = abc -a "$BAR" = foo'`},
+	}, repo, nil, false, log)
+	assert.Equal("success", result.Conclusion)
+
+	result = testAndSaveCoverage(context.Background(), ref, "allow_failure", util.TestsConfig{
+		Cmds:         []string{"false"},
+		AllowFailure: true,
+	}, repo, nil, false, log)
+	assert.Equal("neutral", result.Conclusion)
+}
+
+func TestTestShell(t *testing.T) {
+	assert := assert.New(t)
+
+	_, filepath, _, _ := runtime.Caller(0)
+	repo := path.Dir(filepath) + "/../../testdata/go"
+	ref := common.GithubRef{CheckType: common.CheckTypeBranch, CheckRef: "master"}
+
+	log := new(strings.Builder)
+	result := testAndSaveCoverage(context.Background(), ref, "script", util.TestsConfig{
+		Script: "test \"$PROJECT_NAME\" = go\nfalse\necho un\"\"reachable\n",
+	}, repo, nil, false, log)
+	assert.Equal("failure", result.Conclusion)
+	assert.NotContains(result.OutputSummary, "unreachable\n")
+
+	result = testAndSaveCoverage(context.Background(), ref, "pipefail", util.TestsConfig{
+		Shell:  "bash",
+		Script: "false | cat\n",
+	}, repo, nil, false, log)
+	assert.Equal("failure", result.Conclusion)
+
+	result = testAndSaveCoverage(context.Background(), ref, "cmds", util.TestsConfig{
+		Shell: "bash",
+		Cmds:  []string{"test \"$CI_CHECK_REF\" = master && echo $FOO | grep -q bar"},
+		Env:   map[string]string{"FOO": "bar"},
+	}, repo, nil, false, log)
+	assert.Equal("success", result.Conclusion)
+}
+
+func TestTestSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	_, filepath, _, _ := runtime.Caller(0)
+	repo := path.Dir(filepath) + "/../../testdata/go"
+	ref := common.GithubRef{Secrets: map[string]string{"API_TOKEN": "s3cr3t"}}
+
+	log := new(strings.Builder)
+	result := testAndSaveCoverage(context.Background(), ref, "secrets", util.TestsConfig{
+		Cmds: []string{`sh -c 'echo token: $API_TOKEN'`},
+	}, repo, nil, false, log)
+	assert.Equal("success", result.Conclusion)
+	assert.Contains(result.OutputSummary, "token: ***\n")
+	assert.Contains(log.String(), "token: ***\n")
+	assert.NotContains(result.OutputSummary+log.String(), "s3cr3t")
+}
+
+func TestTestEnvironCIVariables(t *testing.T) {
+	assert := assert.New(t)
+
+	ref := common.GithubRef{
+		Sha:     "abc",
+		Env:     map[string]string{"FOO": "project"},
+		Secrets: map[string]string{"CI": "false", "API_TOKEN": "s3cr3t"},
+	}
+	env := testEnviron("/tmp/repo", ref, util.TestsConfig{
+		Env: map[string]string{"CI_COMMIT_SHA": "x", "FOO": "test"},
+	})
+	assert.Contains(env, "CI=true")
+	assert.Contains(env, "CI_COMMIT_SHA=abc")
+	assert.Contains(env, "API_TOKEN=s3cr3t")
+	assert.Equal("FOO=test", env[len(env)-1])
+	assert.NotContains(env, "CI=false")
+	assert.NotContains(env, "CI_COMMIT_SHA=x")
+
+	parser := newTestShellParser("/tmp/repo", "/tmp/repo", ref, map[string]string{"CI_COMMIT_SHA": "x"})
+	words, err := parser.Parse("echo $CI $CI_COMMIT_SHA $API_TOKEN")
+	assert.NoError(err)
+	assert.Equal([]string{"echo", "true", "abc", "s3cr3t"}, words)
+}
@@ -0,0 +1,95 @@
+package tester
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// annotationLevel maps the severity of a problem to the annotation level
+func annotationLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "warning", "warn":
+		return "warning"
+	case "notice", "note", "info":
+		return "notice"
+	}
+	return "failure"
+}
+
+// repoRelativePath returns the path of file relative to the repo, file may be
+// absolute or relative to dir
+func repoRelativePath(repoPath, dir, file string) (string, bool) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	absRepoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(absRepoPath, file)
+	if err != nil {
+		return "", false
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// matchProblems turns the lines of the output matched by the matchers into
+// annotations, the problems of the files outside of the repo are ignored.
+func matchProblems(matchers []util.ProblemMatcher, output, repoPath, dir string) []*github.CheckRunAnnotation {
+	var annotations []*github.CheckRunAnnotation
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	for _, m := range matchers {
+		r, err := m.Compile()
+		if err != nil {
+			// validated when reading the config
+			continue
+		}
+		for _, line := range strings.Split(output, "\n") {
+			match := r.FindStringSubmatch(strings.TrimRight(line, "\r"))
+			if match == nil {
+				continue
+			}
+			groups := make(map[string]string)
+			for i, name := range r.SubexpNames() {
+				if name != "" {
+					groups[name] = strings.TrimSpace(match[i])
+				}
+			}
+			path, ok := repoRelativePath(repoPath, absDir, groups["file"])
+			if !ok {
+				continue
+			}
+			startLine, err := strconv.Atoi(groups["line"])
+			if err != nil || startLine <= 0 {
+				continue
+			}
+			severity := groups["severity"]
+			if severity == "" {
+				severity = m.Severity
+			}
+			level := annotationLevel(severity)
+			message := groups["message"]
+			if column, err := strconv.Atoi(groups["column"]); err == nil && column > 0 {
+				message = strconv.Itoa(startLine) + ":" + strconv.Itoa(column) + " " + message
+			}
+			annotations = append(annotations, &github.CheckRunAnnotation{
+				Path:            &path,
+				Message:         &message,
+				StartLine:       &startLine,
+				EndLine:         &startLine,
+				AnnotationLevel: &level,
+			})
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,49 @@
+package tester
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestMatchProblems(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+	dir := filepath.Join(repoPath, "web")
+
+	matchers := []util.ProblemMatcher{
+		{Regexp: `^(?P<file>[^:\s]+):(?P<line>\d+):(?P<column>\d+): (?P<message>.+)$`},
+		{Regexp: `^(?P<severity>warning|notice) (?P<file>\S+) line (?P<line>\d+): (?P<message>.+)$`, Severity: "error"},
+	}
+	output := "ok\n" +
+		"src/app.js:12:3: something broke\r\n" +
+		filepath.Join(repoPath, "main.go") + ":5:1: absolute path\n" +
+		"/etc/passwd:1:1: outside of the repo\n" +
+		"../../outside.go:1:1: outside of the repo\n" +
+		"warning index.html line 7: deprecated tag\n" +
+		"notice index.html line x: not a line\n"
+
+	annotations := matchProblems(matchers, output, repoPath, dir)
+	require.Len(annotations, 3)
+
+	assert.Equal("web/src/app.js", annotations[0].GetPath())
+	assert.Equal(12, annotations[0].GetStartLine())
+	assert.Equal("12:3 something broke", annotations[0].GetMessage())
+	assert.Equal("failure", annotations[0].GetAnnotationLevel())
+
+	assert.Equal("main.go", annotations[1].GetPath())
+
+	assert.Equal("web/index.html", annotations[2].GetPath())
+	assert.Equal(7, annotations[2].GetEndLine())
+	assert.Equal("deprecated tag", annotations[2].GetMessage())
+	assert.Equal("warning", annotations[2].GetAnnotationLevel())
+}
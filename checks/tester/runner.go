@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -17,10 +20,18 @@ type Result struct {
 	Conclusion    string
 	ReportMessage string
 	OutputSummary string
+	// Violation is the exceeded resource limit, e.g. "killed: memory limit exceeded"
+	Violation string
+	// ArtifactsURL is the download url of the archived artifacts
+	ArtifactsURL string
+	// Annotations are the problems matched in the output
+	Annotations []*github.CheckRunAnnotation
 }
 
 type Runner interface {
 	Run(ctx context.Context, testName string, testConfig util.TestsConfig) (*Result, error)
+	// Skip reports the test as skipped without running it
+	Skip(ctx context.Context, testName string, testConfig util.TestsConfig, reason string) *Result
 }
 
 func isEmptyTest(cmds []string) bool {
@@ -33,7 +44,20 @@ func isEmptyTest(cmds []string) bool {
 	return empty
 }
 
+type testState struct {
+	done   chan struct{}
+	passed bool
+}
+
+// RunTests runs the tests concurrently after the tests they need,
+// the tests whose needs did not pass will be skipped.
 func RunTests(ctx context.Context, tests map[string]util.TestsConfig, t Runner, coverageMap *sync.Map) (failedTests, passedTests, errTests int) {
+	order, err := util.SortTests(tests)
+	if err != nil {
+		common.LogError.Errorf("RunTests error: %v", err)
+		return 0, 0, len(tests)
+	}
+
 	maxPendingTests := common.Conf.Concurrency.Test
 	if maxPendingTests < 1 {
 		maxPendingTests = 1
@@ -46,24 +70,50 @@ func RunTests(ctx context.Context, tests map[string]util.TestsConfig, t Runner,
 		failedCount int64
 		passedCount int64
 	)
-	for k, v := range tests {
+	states := make(map[string]*testState, len(order))
+	for _, testName := range order {
+		states[testName] = &testState{done: make(chan struct{})}
+	}
+	for _, k := range order {
 		testName := k
-		testConfig := v
+		testConfig := tests[k]
+		state := states[testName]
 
-		if isEmptyTest(testConfig.Cmds) {
+		if isEmptyTest(testConfig.Commands()) {
+			state.passed = true
+			close(state.done)
 			continue
 		}
 
-		pendingTests <- 0
 		wg.Add(1)
 		go func() {
 			defer func() {
 				if info := recover(); info != nil {
 					atomic.AddInt64(&errCount, 1)
 				}
+				close(state.done)
 				wg.Done()
-				<-pendingTests
 			}()
+
+			// wait for the needs before taking a slot
+			var failedNeeds []string
+			for _, need := range testConfig.Needs {
+				<-states[need].done
+				if !states[need].passed {
+					failedNeeds = append(failedNeeds, need)
+				}
+			}
+			if len(failedNeeds) > 0 {
+				reason := fmt.Sprintf("skipped: %s did not pass", strings.Join(failedNeeds, ", "))
+				result := t.Skip(ctx, testName, testConfig, reason)
+				if testConfig.Coverage != "" {
+					coverageMap.Store(testName, result.ReportMessage)
+				}
+				return
+			}
+
+			pendingTests <- 0
+			defer func() { <-pendingTests }()
 			result, err := t.Run(ctx, testName, testConfig)
 			if testConfig.Coverage != "" {
 				coverageMap.Store(testName, result.ReportMessage)
@@ -75,6 +125,7 @@ func RunTests(ctx context.Context, tests map[string]util.TestsConfig, t Runner,
 					atomic.AddInt64(&errCount, 1)
 				}
 			} else {
+				state.passed = true
 				atomic.AddInt64(&passedCount, 1)
 			}
 		}()
@@ -87,6 +138,16 @@ func RunTests(ctx context.Context, tests map[string]util.TestsConfig, t Runner,
 	return
 }
 
+// skippedResult returns the result of a skipped test
+func skippedResult(testName, reason string, log io.Writer) *Result {
+	_, _ = io.WriteString(log, fmt.Sprintf("Testing '%s'\n%s\n\n", testName, reason))
+	return &Result{
+		Conclusion:    "skipped",
+		ReportMessage: "skipped",
+		OutputSummary: reason + "\n",
+	}
+}
+
 func LoadBaseFromStore(ref common.GithubRef, baseSHA string, tests map[string]util.TestsConfig,
 	log io.Writer) ([]store.CommitsInfo, map[string]util.TestsConfig) {
 	baseSavedRecords, err := store.ListCommitsInfo(ref.Owner, ref.RepoName, baseSHA)
@@ -114,13 +175,33 @@ func LoadBaseFromStore(ref common.GithubRef, baseSHA string, tests map[string]ut
 			baseTestsNeedToRun[testName] = testCfg
 		}
 	}
+	// the needs have to be run before the tests
+	var addNeeds func(testCfg util.TestsConfig)
+	addNeeds = func(testCfg util.TestsConfig) {
+		for _, need := range testCfg.Needs {
+			if _, ok := baseTestsNeedToRun[need]; ok {
+				continue
+			}
+			if needCfg, ok := tests[need]; ok {
+				// no coverage will be saved for the needs
+				needCfg.Coverage = ""
+				baseTestsNeedToRun[need] = needCfg
+				addNeeds(needCfg)
+			}
+		}
+	}
+	for _, testCfg := range baseTestsNeedToRun {
+		addNeeds(testCfg)
+	}
 	io.WriteString(log,
 		fmt.Sprintf("baseSavedRecords: %d, baseTestsNeedToRun: %d\n\n", len(baseSavedRecords), len(baseTestsNeedToRun)))
 	return baseSavedRecords, baseTestsNeedToRun
 }
 
+// FindBaseCoverage loads the base coverage from the saved records, and runs the
+// tests not saved in a separate git worktree of the base commit.
 func FindBaseCoverage(ctx context.Context, baseSavedRecords []store.CommitsInfo, baseTestsNeedToRun map[string]util.TestsConfig, repoPath string,
-	baseSHA string, gpull *github.PullRequest, ref common.GithubRef, log io.Writer, baseCoverage *sync.Map) error {
+	baseSHA string, gpull *github.PullRequest, ref common.GithubRef, cache util.CacheConfig, log io.Writer, baseCoverage *sync.Map) error {
 	for _, v := range baseSavedRecords {
 		if v.Coverage == nil {
 			baseCoverage.Store(v.Test, "nil")
@@ -130,36 +211,83 @@ func FindBaseCoverage(ctx context.Context, baseSavedRecords []store.CommitsInfo,
 	}
 
 	if len(baseTestsNeedToRun) > 0 {
-		io.WriteString(log, "$ git checkout -f "+baseSHA+"\n")
-		gitCmds := []string{"checkout", "-f", baseSHA}
-		err := util.RunGitCommand(ref, repoPath, gitCmds, log)
+		worktreePath, err := AddBaseWorktree(ref, repoPath, baseSHA, log)
 		if err != nil {
-			msg := fmt.Sprintf("Failed to checkout to base: %v\n", err)
+			msg := fmt.Sprintf("Failed to create worktree of base: %v\n", err)
 			common.LogError.Error(msg)
 			io.WriteString(log, msg)
 			return err
 		}
+		defer RemoveBaseWorktree(ref, repoPath, worktreePath, log)
+
+		if cache.Enabled() {
+			restoreBaseCache(ref, worktreePath, cache, log)
+		}
 
 		t := &baseTest{
 			Ref:      ref,
 			BaseSHA:  baseSHA,
-			RepoPath: repoPath,
+			RepoPath: worktreePath,
 			Pull:     gpull,
 		}
 		t.LogDivider = util.NewLogDivider(len(baseTestsNeedToRun) > 1, log)
 		RunTests(ctx, baseTestsNeedToRun, t, baseCoverage)
+	}
+	return nil
+}
 
-		io.WriteString(log, "$ git checkout -f "+ref.Sha+"\n")
-		gitCmds = []string{"checkout", "-f", ref.Sha}
-		err = util.RunGitCommand(ref, repoPath, gitCmds, log)
-		if err != nil {
-			msg := fmt.Sprintf("Failed to checkout back: %v\n", err)
-			common.LogError.Error(msg)
-			io.WriteString(log, msg)
-			return err
+// AddBaseWorktree checks out the base commit in a new temporary worktree,
+// outside of the work dir so that it will not be taken as a repo
+func AddBaseWorktree(ref common.GithubRef, repoPath, baseSHA string, log io.Writer) (string, error) {
+	worktreePath, err := ioutil.TempDir("", "unified-ci-base-")
+	if err != nil {
+		return "", err
+	}
+	io.WriteString(log, "$ git worktree add --detach "+worktreePath+" "+baseSHA+"\n")
+	gitCmds := []string{"worktree", "add", "--detach", worktreePath, baseSHA}
+	err = util.RunGitCommand(ref, repoPath, gitCmds, log)
+	if err != nil {
+		os.RemoveAll(worktreePath)
+		return "", err
+	}
+	return worktreePath, nil
+}
+
+// RemoveBaseWorktree removes the worktree and its administrative files
+func RemoveBaseWorktree(ref common.GithubRef, repoPath, worktreePath string, log io.Writer) {
+	io.WriteString(log, "$ git worktree remove --force "+worktreePath+"\n")
+	gitCmds := []string{"worktree", "remove", "--force", worktreePath}
+	err := util.RunGitCommand(ref, repoPath, gitCmds, log)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to remove worktree of base: %v\n", err)
+		common.LogError.Error(msg)
+		io.WriteString(log, msg)
+		// PASS: remove it directly
+		os.RemoveAll(worktreePath)
+		_ = util.RunGitCommand(ref, repoPath, []string{"worktree", "prune"}, log)
+	}
+}
+
+// restoreBaseCache restores the dependency cache into the base worktree, which
+// is not saved as the base is not the commit being checked
+func restoreBaseCache(ref common.GithubRef, worktreePath string, cache util.CacheConfig, log io.Writer) {
+	key, err := cache.Key(worktreePath)
+	if err == nil {
+		var hit bool
+		hit, err = util.RestoreCache(common.Conf.Core.CacheDir, ref.Owner, ref.RepoName, key, cache, worktreePath)
+		if err == nil {
+			if hit {
+				io.WriteString(log, "Base cache hit: "+key+"\n\n")
+			} else {
+				io.WriteString(log, "Base cache miss: "+key+"\n\n")
+			}
+			return
 		}
 	}
-	return nil
+	msg := fmt.Sprintf("Failed to restore base cache: %v\n", err)
+	common.LogError.Error(msg)
+	io.WriteString(log, msg)
+	// PASS
 }
 
 type baseTest struct {
@@ -180,10 +308,20 @@ func (t *baseTest) Run(ctx context.Context, testName string, testConfig util.Tes
 			ref.CheckType = common.CheckTypePRBase
 		}
 
-		result = testAndSaveCoverage(ctx, ref,
-			testName, testConfig.Cmds, testConfig.Coverage, testConfig.DeltaCoverage, t.RepoPath, t.Pull, true, w)
+		result = testAndSaveCoverage(ctx, ref, testName, testConfig, t.RepoPath, t.Pull, true, w)
 	})
-	return result, nil
+	var err error
+	if result.Conclusion != "success" && result.Conclusion != "neutral" {
+		err = &testNotPass{Title: result.Conclusion}
+	}
+	return result, err
+}
+
+func (t *baseTest) Skip(ctx context.Context, testName string, testConfig util.TestsConfig, reason string) (result *Result) {
+	t.Log(func(w io.Writer) {
+		result = skippedResult(testName, reason, w)
+	})
+	return
 }
 
 type HeadTest struct {
@@ -198,11 +336,17 @@ type HeadTest struct {
 
 func (t *HeadTest) Run(ctx context.Context, testName string, testConfig util.TestsConfig) (result *Result, err error) {
 	t.Log(func(w io.Writer) {
-		result = testAndSaveCoverage(ctx, t.Ref,
-			testName, testConfig.Cmds, testConfig.Coverage, testConfig.DeltaCoverage, t.RepoPath, t.Pull, false, w)
-		if result.Conclusion == "failure" {
-			err = &testNotPass{Title: ""}
+		result = testAndSaveCoverage(ctx, t.Ref, testName, testConfig, t.RepoPath, t.Pull, false, w)
+		if result.Conclusion == "failure" || result.Conclusion == "timed_out" {
+			err = &testNotPass{Title: result.Conclusion}
 		}
 	})
 	return
 }
+
+func (t *HeadTest) Skip(ctx context.Context, testName string, testConfig util.TestsConfig, reason string) (result *Result) {
+	t.Log(func(w io.Writer) {
+		result = skippedResult(testName, reason, w)
+	})
+	return
+}
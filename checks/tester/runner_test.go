@@ -2,6 +2,7 @@ package tester
 
 import (
 	"context"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
@@ -98,7 +99,7 @@ func TestGetBaseCoverage(t *testing.T) {
 					Login: &author,
 				},
 			},
-		}, ref, os.Stdout, &baseCoverage)
+		}, ref, util.CacheConfig{}, os.Stdout, &baseCoverage)
 	require.NoError(err)
 
 	value, _ := baseCoverage.Load("go")
@@ -110,3 +111,92 @@ func TestGetBaseCoverage(t *testing.T) {
 	assert.Len(baseSavedRecords, 1)
 	assert.True(*baseSavedRecords[0].Coverage > 0)
 }
+
+type fakeRunner struct {
+	mu      sync.Mutex
+	ran     []string
+	skipped map[string]string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, testName string, testConfig util.TestsConfig) (*Result, error) {
+	r.mu.Lock()
+	r.ran = append(r.ran, testName)
+	r.mu.Unlock()
+	if testConfig.Cmds[0] == "false" {
+		return &Result{Conclusion: "failure"}, &testNotPass{}
+	}
+	return &Result{Conclusion: "success"}, nil
+}
+
+func (r *fakeRunner) Skip(ctx context.Context, testName string, testConfig util.TestsConfig, reason string) *Result {
+	r.mu.Lock()
+	r.skipped[testName] = reason
+	r.mu.Unlock()
+	return &Result{Conclusion: "skipped", ReportMessage: "skipped"}
+}
+
+func TestRunTestsNeeds(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := map[string]util.TestsConfig{
+		"build": {Cmds: []string{"true"}},
+		"unit":  {Cmds: []string{"false"}, Needs: []string{"build"}},
+		"lint":  {Cmds: []string{"true"}, Needs: []string{"build"}},
+		"e2e":   {Cmds: []string{"true"}, Needs: []string{"unit", "lint"}, Coverage: "(.*)"},
+		"after": {Cmds: []string{"true"}, Needs: []string{"e2e"}},
+	}
+	r := &fakeRunner{skipped: make(map[string]string)}
+	var coverage sync.Map
+	failed, passed, errs := RunTests(context.TODO(), tests, r, &coverage)
+	assert.Equal(1, failed)
+	assert.Equal(2, passed)
+	assert.Equal(0, errs)
+	assert.Equal("build", r.ran[0])
+	assert.ElementsMatch([]string{"build", "unit", "lint"}, r.ran)
+	assert.Equal(map[string]string{
+		"e2e":   "skipped: unit did not pass",
+		"after": "skipped: e2e did not pass",
+	}, r.skipped)
+	value, _ := coverage.Load("e2e")
+	assert.Equal("skipped", value)
+}
+
+func TestBaseWorktree(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+
+	git := func(args ...string) string {
+		var out strings.Builder
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=user@test.com"}, args...)...)
+		cmd.Dir = repoPath
+		cmd.Stdout = &out
+		require.NoError(cmd.Run())
+		return strings.TrimSpace(out.String())
+	}
+	git("init")
+	require.NoError(ioutil.WriteFile(path.Join(repoPath, "file"), []byte("base"), 0644))
+	git("add", "file")
+	git("commit", "-m", "base")
+	baseSHA := git("rev-parse", "HEAD")
+	require.NoError(ioutil.WriteFile(path.Join(repoPath, "file"), []byte("head"), 0644))
+	git("commit", "-am", "head")
+
+	ref := common.GithubRef{Owner: "owner", RepoName: "repo", Sha: git("rev-parse", "HEAD")}
+	worktreePath, err := AddBaseWorktree(ref, repoPath, baseSHA, ioutil.Discard)
+	require.NoError(err)
+	content, err := ioutil.ReadFile(path.Join(worktreePath, "file"))
+	require.NoError(err)
+	assert.Equal("base", string(content))
+	content, err = ioutil.ReadFile(path.Join(repoPath, "file"))
+	require.NoError(err)
+	assert.Equal("head", string(content))
+
+	RemoveBaseWorktree(ref, repoPath, worktreePath, ioutil.Discard)
+	_, err = os.Stat(worktreePath)
+	assert.True(os.IsNotExist(err))
+	assert.Len(strings.Split(git("worktree", "list"), "\n"), 1)
+}
@@ -1,5 +1,10 @@
 package common
 
+import (
+	"errors"
+	"strings"
+)
+
 // Language type
 type Language string
 
@@ -9,8 +14,14 @@ const (
 	Java   Language = "java"
 	PHP    Language = "php"
 	NodeJS Language = "nodejs"
+	Python Language = "python"
+	Rust   Language = "rust"
+	Ruby   Language = "ruby"
 )
 
+// ErrNotReady is returned by the queries before the scanning is finished
+var ErrNotReady = errors.New("the scanning result is not ready")
+
 // Data type of the checking result
 type Data struct {
 	Link       string `json:"link"`
@@ -21,6 +32,22 @@ type Data struct {
 	UpdatedAt  string `json:"updated_at"`
 	Version    string `json:"version"`
 	VulRisk    string `json:"vul_risk"`
+	// Suppression is set if the vulnerability is ignored by the project
+	Suppression *Suppression `json:"suppression,omitempty"`
+}
+
+// Suppression is why and until when a vulnerability is accepted
+type Suppression struct {
+	Reason string `json:"reason"`
+	Until  string `json:"until,omitempty"`
+	// Expired is true if the vulnerability is no longer ignored
+	Expired bool `json:"expired,omitempty"`
+}
+
+// Suppressed reports whether the vulnerability is ignored, the expired
+// suppressions do not count
+func (d Data) Suppressed() bool {
+	return d.Suppression != nil && !d.Suppression.Expired
 }
 
 // MDTitle returns the title of the markdown table used to report data
@@ -30,5 +57,16 @@ func (Data) MDTitle() string {
 
 // MDTableRow formats Data d into a row of the markdown table
 func (d Data) MDTableRow() string {
-	return "|" + d.VulRisk + "|" + d.Name + "|" + d.Version + "|" + d.VulTitle + "|\n"
+	desc := d.VulTitle
+	if d.Suppression != nil {
+		note := "suppressed"
+		if d.Suppression.Expired {
+			note = "**suppression expired on " + d.Suppression.Until + "**"
+		} else if d.Suppression.Until != "" {
+			note += " until " + d.Suppression.Until
+		}
+		desc += "<br>" + note + ": " + d.Suppression.Reason
+	}
+	desc = strings.ReplaceAll(desc, "|", "\\|")
+	return "|" + d.VulRisk + "|" + d.Name + "|" + d.Version + "|" + desc + "|\n"
 }
@@ -0,0 +1,52 @@
+package vulnerability
+
+import (
+	"strings"
+
+	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
+)
+
+// riskLevels ranks the risks reported by the providers, the unknown ones are 0
+var riskLevels = map[string]int{
+	"low":      1,
+	"moderate": 2,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// RiskLevel returns the rank of the risk, the higher the worse
+func RiskLevel(risk string) int {
+	return riskLevels[strings.ToLower(strings.TrimSpace(risk))]
+}
+
+// key identifies the vulnerability of the package regardless of its version,
+// so that upgrading to another vulnerable version does not make it new.
+func key(d vulcommon.Data) string {
+	id := d.Link
+	if id == "" {
+		id = d.VulTitle
+	}
+	return d.VulProduct + "\x00" + d.Name + "\x00" + id
+}
+
+// Diff compares the vulnerabilities of head with base, it returns the ones
+// introduced or worsened by head, and the ones already existing in base.
+func Diff(head, base []vulcommon.Data) (introduced, existing []vulcommon.Data) {
+	baseRisks := make(map[string]int, len(base))
+	for _, d := range base {
+		k := key(d)
+		if level, ok := baseRisks[k]; !ok || RiskLevel(d.VulRisk) > level {
+			baseRisks[k] = RiskLevel(d.VulRisk)
+		}
+	}
+	for _, d := range head {
+		level, ok := baseRisks[key(d)]
+		if ok && RiskLevel(d.VulRisk) <= level {
+			existing = append(existing, d)
+		} else {
+			introduced = append(introduced, d)
+		}
+	}
+	return
+}
@@ -0,0 +1,39 @@
+package vulnerability
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
+)
+
+func TestDiff(t *testing.T) {
+	assert := assert.New(t)
+
+	base := []vulcommon.Data{
+		{Link: "https://osv.dev/vulnerability/A", Name: "lodash", Version: "4.17.15", VulRisk: "moderate"},
+		{Link: "https://osv.dev/vulnerability/B", Name: "express", Version: "4.16.0", VulRisk: "high"},
+		{VulTitle: "C", Name: "monolog/monolog", Version: "1.25.1", VulRisk: "low"},
+	}
+	head := []vulcommon.Data{
+		// upgraded to another vulnerable version
+		{Link: "https://osv.dev/vulnerability/A", Name: "lodash", Version: "4.17.16", VulRisk: "moderate"},
+		// the risk is raised
+		{VulTitle: "C", Name: "monolog/monolog", Version: "1.25.2", VulRisk: "high"},
+		{Link: "https://osv.dev/vulnerability/D", Name: "lodash", Version: "4.17.16", VulRisk: "low"},
+	}
+	introduced, existing := Diff(head, base)
+	assert.Equal([]vulcommon.Data{head[1], head[2]}, introduced)
+	assert.Equal([]vulcommon.Data{head[0]}, existing)
+
+	introduced, existing = Diff(head, nil)
+	assert.Equal(head, introduced)
+	assert.Empty(existing)
+	introduced, existing = Diff(nil, base)
+	assert.Empty(introduced)
+	assert.Empty(existing)
+
+	assert.Equal(4, RiskLevel("Critical"))
+	assert.Equal(RiskLevel("moderate"), RiskLevel("medium"))
+	assert.Equal(0, RiskLevel("unknown"))
+}
@@ -0,0 +1,413 @@
+package osv
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/checks/vulnerability/common"
+)
+
+// error definitions
+var (
+	ErrNoDatabase = errors.New("osv database path is not configured")
+)
+
+// Event is an event of the affected range, only one of the fields is set
+type Event struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+}
+
+// Range is a range of the affected versions
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Affected is an affected package of the vulnerability
+type Affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// Vulnerability is an entry in the OSV format, see https://ossf.github.io/osv-schema/
+type Vulnerability struct {
+	ID       string     `json:"id"`
+	Summary  string     `json:"summary"`
+	Details  string     `json:"details"`
+	Modified string     `json:"modified"`
+	Aliases  []string   `json:"aliases"`
+	Affected []Affected `json:"affected"`
+	// DatabaseSpecific has the severity of the GitHub advisories
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	References []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"references"`
+}
+
+// Link returns the advisory link of the vulnerability
+func (v *Vulnerability) Link() string {
+	for _, t := range []string{"ADVISORY", "WEB"} {
+		for _, r := range v.References {
+			if r.Type == t {
+				return r.URL
+			}
+		}
+	}
+	return "https://osv.dev/vulnerability/" + v.ID
+}
+
+// Severity returns the severity of the vulnerability, "unknown" if not provided
+func (v *Vulnerability) Severity() string {
+	if v.DatabaseSpecific.Severity == "" {
+		return "unknown"
+	}
+	return strings.ToLower(v.DatabaseSpecific.Severity)
+}
+
+// Title returns the ID with the summary of the vulnerability
+func (v *Vulnerability) Title() string {
+	summary := v.Summary
+	if summary == "" {
+		summary = strings.SplitN(strings.TrimSpace(v.Details), "\n", 2)[0]
+	}
+	if summary == "" {
+		return v.ID
+	}
+	return v.ID + ": " + summary
+}
+
+// Affects checks if the version of the package is affected, it also returns
+// the version fixing the vulnerability if known.
+func (a *Affected) Affects(ver string) (bool, string) {
+	for _, v := range a.Versions {
+		if v == ver || dependency.CompareVersions(v, ver) == 0 {
+			return true, a.fixed(ver)
+		}
+	}
+	if !dependency.ValidVersion(ver) {
+		// e.g. dev-master of composer
+		return false, ""
+	}
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			// GIT ranges are the commits
+			continue
+		}
+		if r.affects(ver) {
+			return true, a.fixed(ver)
+		}
+	}
+	return false, ""
+}
+
+// fixed returns the lowest fixed version greater than ver
+func (a *Affected) fixed(ver string) string {
+	fixed := ""
+	for _, r := range a.Ranges {
+		for _, e := range r.Events {
+			if e.Fixed != "" && dependency.CompareVersions(e.Fixed, ver) > 0 &&
+				(fixed == "" || dependency.CompareVersions(e.Fixed, fixed) < 0) {
+				fixed = e.Fixed
+			}
+		}
+	}
+	return fixed
+}
+
+// eventVersion returns the version of the event, "0" introduced is the lowest
+func eventVersion(e Event) string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	}
+	return e.LastAffected
+}
+
+// affects evaluates the events in the order of their versions
+func (r Range) affects(ver string) bool {
+	events := append([]Event(nil), r.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := eventVersion(events[i]), eventVersion(events[j])
+		if a == "0" || b == "0" {
+			return a == "0" && b != "0"
+		}
+		return dependency.CompareVersions(a, b) < 0
+	})
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || dependency.CompareVersions(ver, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if dependency.CompareVersions(ver, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if dependency.CompareVersions(ver, e.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+type packageKey struct {
+	Ecosystem string
+	Name      string
+}
+
+// Database is the vulnerabilities indexed by the affected packages
+type Database struct {
+	stamp   databaseStamp
+	entries map[packageKey][]*Vulnerability
+}
+
+// databaseStamp changes with any of the files loaded into the database, as
+// the files in the subdirectories are updated without changing the mtime of
+// the database directory
+type databaseStamp struct {
+	modTime time.Time
+	files   int
+}
+
+// databaseFile reports whether the file is loaded into the database
+func databaseFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".json" || ext == ".zip"
+}
+
+// statDatabase returns the newest mtime of the files and the directories of
+// the database, and the number of them
+func statDatabase(path string) (databaseStamp, error) {
+	var stamp databaseStamp
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !databaseFile(p) {
+			return nil
+		}
+		if info.ModTime().After(stamp.modTime) {
+			stamp.modTime = info.ModTime()
+		}
+		stamp.files++
+		return nil
+	})
+	return stamp, err
+}
+
+// LoadDatabase loads the OSV entries from the JSON files and the zip exports
+// like "all.zip" in the directory path, recursively.
+func LoadDatabase(path string) (*Database, error) {
+	db := &Database{entries: make(map[packageKey][]*Vulnerability)}
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !databaseFile(p) {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json":
+			content, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return db.add(p, content)
+		case ".zip":
+			return db.addZip(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *Database) addZip(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err = db.add(path+":"+f.Name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Database) add(name string, content []byte) error {
+	v := new(Vulnerability)
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("invalid osv entry %s: %v", name, err)
+	}
+	seen := make(map[packageKey]bool)
+	for _, a := range v.Affected {
+		key := packageKey{Ecosystem: a.Package.Ecosystem, Name: a.Package.Name}
+		if !seen[key] {
+			seen[key] = true
+			db.entries[key] = append(db.entries[key], v)
+		}
+	}
+	return nil
+}
+
+// Match returns the vulnerabilities affecting the version of the dependency,
+// and the versions fixing them respectively.
+func (db *Database) Match(pkg dependency.Dependency) ([]*Vulnerability, []string) {
+	var vulns []*Vulnerability
+	var fixed []string
+	for _, v := range db.entries[packageKey{Ecosystem: pkg.Ecosystem, Name: pkg.Name}] {
+		for i := range v.Affected {
+			a := &v.Affected[i]
+			if a.Package.Ecosystem != pkg.Ecosystem || a.Package.Name != pkg.Name {
+				continue
+			}
+			if ok, fix := a.Affects(pkg.Version); ok {
+				vulns = append(vulns, v)
+				fixed = append(fixed, fix)
+				break
+			}
+		}
+	}
+	return vulns, fixed
+}
+
+var (
+	databases   = make(map[string]*Database)
+	databasesMu sync.Mutex
+)
+
+// openDatabase returns the loaded database of the path, it is reloaded when
+// any of its files changes.
+func openDatabase(path string) (*Database, error) {
+	if path == "" {
+		return nil, ErrNoDatabase
+	}
+	stamp, err := statDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	databasesMu.Lock()
+	defer databasesMu.Unlock()
+	if db, ok := databases[path]; ok && db.stamp == stamp {
+		return db, nil
+	}
+	db, err := LoadDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	db.stamp = stamp
+	databases[path] = db
+	return db, nil
+}
+
+// Scanner implements the vulnerability.Scanner interface with a local mirror
+// of the OSV database
+type Scanner struct {
+	AppName string
+	// Path is the directory of the OSV database
+	Path     string
+	commitID string
+	context  string
+	packages map[common.Language][]dependency.Dependency
+}
+
+// CheckPackages parses the packages listed in pkgFilePath file, such as "go.sum"
+func (s *Scanner) CheckPackages(ctx context.Context, lang common.Language, pkgFilePath string) (bool, error) {
+	pkgs, err := dependency.Parse(pkgFilePath)
+	if err != nil {
+		return false, err
+	}
+	if len(pkgs) == 0 {
+		return false, nil
+	}
+	if s.packages == nil {
+		s.packages = make(map[common.Language][]dependency.Dependency)
+	}
+	s.packages[lang] = append(s.packages[lang], pkgs...)
+	return true, nil
+}
+
+// Query matches the packages of lang with the database, the result is always
+// ready as the database is local
+func (s *Scanner) Query(ctx context.Context, lang common.Language) ([]common.Data, error) {
+	pkgs := s.packages[lang]
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	db, err := openDatabase(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var result []common.Data
+	for _, pkg := range pkgs {
+		vulns, fixed := db.Match(pkg)
+		for i, v := range vulns {
+			title := v.Title()
+			if fixed[i] != "" {
+				title += " (fixed in " + fixed[i] + ")"
+			}
+			result = append(result, common.Data{
+				Link:       v.Link(),
+				VulTitle:   title,
+				AppName:    s.AppName,
+				Name:       pkg.Name,
+				VulProduct: pkg.Ecosystem,
+				UpdatedAt:  v.Modified,
+				Version:    pkg.Version,
+				VulRisk:    v.Severity(),
+			})
+		}
+	}
+	return result, nil
+}
+
+// SetCommitID set query commit id
+func (s *Scanner) SetCommitID(commitID string) {
+	s.commitID = commitID
+}
+
+// SetContext set query context
+func (s *Scanner) SetContext(context string) {
+	s.context = context
+}
@@ -0,0 +1,172 @@
+package osv
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/checks/dependency"
+	"github.com/tengattack/unified-ci/checks/vulnerability/common"
+)
+
+func TestAffects(t *testing.T) {
+	assert := assert.New(t)
+
+	a := Affected{
+		Ranges: []Range{{Type: "SEMVER", Events: []Event{
+			{Fixed: "1.2.0"}, {Introduced: "0"}, {Introduced: "1.5.0"}, {LastAffected: "1.6.0"},
+		}}},
+	}
+	for ver, affected := range map[string]bool{
+		"0.1.0":  true,
+		"1.1.9":  true,
+		"1.2.0":  false,
+		"1.4.0":  false,
+		"1.5.0":  true,
+		"1.6.0":  true,
+		"1.6.1":  false,
+		"v1.1.0": true,
+	} {
+		ok, _ := a.Affects(ver)
+		assert.Equal(affected, ok, ver)
+	}
+	_, fixed := a.Affects("1.0.0")
+	assert.Equal("1.2.0", fixed)
+	_, fixed = a.Affects("1.5.0")
+	assert.Empty(fixed)
+
+	a = Affected{
+		Ranges:   []Range{{Type: "GIT", Events: []Event{{Introduced: "0"}}}},
+		Versions: []string{"2.0.0"},
+	}
+	ok, _ := a.Affects("2.0.0")
+	assert.True(ok)
+	ok, _ = a.Affects("2.0.1")
+	assert.False(ok)
+}
+
+func TestScanner(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+	scanner := &Scanner{AppName: "test", Path: "../testdata/osv"}
+	_, err := scanner.CheckPackages(ctx, common.Golang, "../testdata/packages/go/go.sum")
+	require.NoError(err)
+	_, err = scanner.CheckPackages(ctx, common.PHP, "../testdata/packages/php/composer.lock")
+	require.NoError(err)
+	_, err = scanner.CheckPackages(ctx, common.NodeJS, "../testdata/packages/npm/package.json")
+	require.NoError(err)
+
+	data, err := scanner.Query(ctx, common.Golang)
+	require.NoError(err)
+	require.Len(data, 2)
+	assert.Equal(common.Data{
+		Link:       "https://osv.dev/vulnerability/GO-2022-0969",
+		VulTitle:   "GO-2022-0969: Denial of service in net/http and golang.org/x/net/http2 (fixed in 0.0.0-20220906165146-f3363e06e74c)",
+		AppName:    "test",
+		Name:       "golang.org/x/net",
+		VulProduct: "Go",
+		UpdatedAt:  "2023-06-12T18:45:41Z",
+		Version:    "v0.0.0-20190620200207-3b0461eec859",
+		VulRisk:    "unknown",
+	}, data[0])
+	assert.Equal("golang.org/x/text", data[1].Name)
+	assert.Equal("v0.3.0", data[1].Version)
+	assert.Equal("https://pkg.go.dev/vuln/GO-2021-0113", data[1].Link)
+
+	data, err = scanner.Query(ctx, common.PHP)
+	require.NoError(err)
+	require.Len(data, 1)
+	assert.Equal("GHSA-3c6g-xr6g-9r3f: Arbitrary file read in monolog", data[0].VulTitle)
+	assert.Equal("moderate", data[0].VulRisk)
+
+	data, err = scanner.Query(ctx, common.NodeJS)
+	require.NoError(err)
+	require.Len(data, 1)
+	assert.Equal("lodash", data[0].Name)
+	assert.Equal("4.17.15", data[0].Version)
+	assert.Equal("high", data[0].VulRisk)
+	assert.Equal("https://nvd.nist.gov/vuln/detail/CVE-2020-8203", data[0].Link)
+
+	_, err = (&Scanner{}).Query(ctx, common.Golang)
+	assert.NoError(err)
+	_, err = (&Scanner{packages: scanner.packages}).Query(ctx, common.Golang)
+	assert.Equal(ErrNoDatabase, err)
+}
+
+func TestLoadDatabaseZip(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "all.zip"))
+	require.NoError(err)
+	zw := zip.NewWriter(f)
+	content, err := ioutil.ReadFile("../testdata/osv/Go/GO-2021-0113.json")
+	require.NoError(err)
+	w, err := zw.Create("GO-2021-0113.json")
+	require.NoError(err)
+	_, err = w.Write(content)
+	require.NoError(err)
+	require.NoError(zw.Close())
+	require.NoError(f.Close())
+
+	db, err := openDatabase(dir)
+	require.NoError(err)
+	vulns, fixed := db.Match(dependency.Dependency{Ecosystem: dependency.Go, Name: "golang.org/x/text", Version: "v0.3.6"})
+	require.Len(vulns, 1)
+	assert.Equal("GO-2021-0113", vulns[0].ID)
+	assert.Equal([]string{"0.3.7"}, fixed)
+	vulns, _ = db.Match(dependency.Dependency{Ecosystem: dependency.Go, Name: "golang.org/x/text", Version: "v0.3.7"})
+	assert.Empty(vulns)
+
+	cached, err := openDatabase(dir)
+	require.NoError(err)
+	assert.True(db == cached)
+}
+
+func TestOpenDatabaseReload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	require.NoError(os.Mkdir(filepath.Join(dir, "Go"), 0755))
+
+	entry := filepath.Join(dir, "Go", "entry.json")
+	content, err := ioutil.ReadFile("../testdata/osv/Go/GO-2021-0113.json")
+	require.NoError(err)
+	require.NoError(ioutil.WriteFile(entry, content, 0644))
+
+	db, err := openDatabase(dir)
+	require.NoError(err)
+	text := dependency.Dependency{Ecosystem: dependency.Go, Name: "golang.org/x/text", Version: "v0.3.6"}
+	vulns, _ := db.Match(text)
+	assert.Len(vulns, 1)
+
+	// overwriting the entry changes neither of the directories
+	info, err := os.Stat(dir)
+	require.NoError(err)
+	content, err = ioutil.ReadFile("../testdata/osv/Go/GO-2022-0969.json")
+	require.NoError(err)
+	require.NoError(ioutil.WriteFile(entry, content, 0644))
+	later := info.ModTime().Add(time.Second)
+	require.NoError(os.Chtimes(entry, later, later))
+
+	reloaded, err := openDatabase(dir)
+	require.NoError(err)
+	assert.False(db == reloaded)
+	vulns, _ = reloaded.Match(text)
+	assert.Empty(vulns)
+}
@@ -2,14 +2,15 @@ package riki
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/tengattack/unified-ci/checks/vulnerability/common"
 	"github.com/tengattack/unified-ci/util"
@@ -19,11 +20,10 @@ import (
 const (
 	postURL = "https://riki.bilibili.co/api/bvd/package/"
 	getURL  = "https://riki.bilibili.co/api/bvd/vul/"
-)
 
-var client = http.Client{
-	Timeout: 5 * time.Second,
-}
+	// statusScanning is the status_code responded until the scanning is finished
+	statusScanning = 1
+)
 
 // error definitions
 var (
@@ -39,14 +39,27 @@ var mapLang = map[common.Language]string{
 
 // Scanner implements the vulnerability.Scanner interface
 type Scanner struct {
-	AppName  string
-	AppFrom  string
+	AppName string
+	AppFrom string
+	// Client is the http client to the riki api, http.DefaultClient if nil
+	Client   *http.Client
 	commitID string
 	context  string
 }
 
-// CheckPackages checks the vulnerability of the packages listed in pkgFilePath file, such as "go.sum"
-func (s *Scanner) CheckPackages(lang common.Language, pkgFilePath string) (bool, error) {
+func (s *Scanner) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+// CheckPackages submits the packages listed in pkgFilePath file, such as "go.sum", to be scanned
+func (s *Scanner) CheckPackages(ctx context.Context, lang common.Language, pkgFilePath string) (bool, error) {
+	if _, ok := mapLang[lang]; !ok {
+		// not supported by riki
+		return false, nil
+	}
 	if !util.FileExists(pkgFilePath) {
 		return false, ErrNotFound
 	}
@@ -75,34 +88,36 @@ func (s *Scanner) CheckPackages(lang common.Language, pkgFilePath string) (bool,
 		return false, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, postURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewReader(body))
 	if err != nil {
 		return false, err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	resp, err := s.client().Do(req)
 	if err != nil {
 		return false, err
 	}
+	resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return false, nil
 	}
 	return true, nil
 }
 
-// Query queries the checking result of CheckPackages
-func (s *Scanner) Query(lang common.Language) ([]common.Data, error) {
+// Query queries the checking result of CheckPackages, it returns
+// common.ErrNotReady until the scanning is finished
+func (s *Scanner) Query(ctx context.Context, lang common.Language) ([]common.Data, error) {
 	q := url.Values{}
 	q.Set("app_name", s.AppName+"-"+mapLang[lang])
 	if s.commitID != "" {
 		q.Set("commit_id", s.commitID)
 	}
 	url := getURL + "?" + q.Encode()
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := client.Do(req)
+	resp, err := s.client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -113,20 +128,23 @@ func (s *Scanner) Query(lang common.Language) ([]common.Data, error) {
 	}
 	var v struct {
 		StatusCode int           `json:"status_code"`
+		Message    string        `json:"message"`
 		Data       []common.Data `json:"data"`
 	}
 	err = json.Unmarshal(data, &v)
 	if err != nil {
 		return nil, err
 	}
+	switch v.StatusCode {
+	case 0:
+	case statusScanning:
+		return nil, common.ErrNotReady
+	default:
+		return nil, fmt.Errorf("riki status_code %d: %s", v.StatusCode, v.Message)
+	}
 	return v.Data, nil
 }
 
-// WaitForQuery waits for query
-func (s *Scanner) WaitForQuery() {
-	time.Sleep(20 * time.Second)
-}
-
 // SetCommitID set query commit id
 func (s *Scanner) SetCommitID(commitID string) {
 	s.commitID = commitID
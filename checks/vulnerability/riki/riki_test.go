@@ -1,7 +1,12 @@
 package riki
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,17 +17,62 @@ func TestCheck(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
+	ctx := context.Background()
 	scanner := &Scanner{AppName: "test"}
-	ok, err := scanner.CheckPackages(common.Java, "../testdata/pom.xml")
+	ok, err := scanner.CheckPackages(ctx, common.Java, "../testdata/pom.xml")
 	require.NoError(err)
 	assert.True(ok)
 
-	scanner.WaitForQuery()
-	data, err := scanner.Query(common.Java)
+	var data []common.Data
+	for i := 0; i < 30; i++ {
+		data, err = scanner.Query(ctx, common.Java)
+		if err != common.ErrNotReady {
+			break
+		}
+		time.Sleep(2 * time.Second)
+	}
 	require.NoError(err)
 	assert.NotEmpty(data)
 }
 
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestQuery(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	body := `{"status_code":1,"data":null}`
+	scanner := &Scanner{AppName: "test", Client: &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			assert.Equal("test-java", r.URL.Query().Get("app_name"))
+			assert.Equal("abc", r.URL.Query().Get("commit_id"))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}}
+	scanner.SetCommitID("abc")
+	_, err := scanner.Query(context.Background(), common.Java)
+	assert.Equal(common.ErrNotReady, err)
+
+	// the permanent errors are not retried
+	body = `{"status_code":400,"message":"app not found","data":null}`
+	_, err = scanner.Query(context.Background(), common.Java)
+	require.Error(err)
+	assert.NotEqual(common.ErrNotReady, err)
+	assert.Equal("riki status_code 400: app not found", err.Error())
+
+	body = `{"status_code":0,"data":[{"name":"fastjson","version":"1.2.49"}]}`
+	data, err := scanner.Query(context.Background(), common.Java)
+	require.NoError(err)
+	assert.Equal([]common.Data{{Name: "fastjson", Version: "1.2.49"}}, data)
+}
+
 var sample = `|level|name|version|description|
 |---|---|---|---|
 |高|fastjson|1.2.49|Fastjson存在命令执行漏洞|
@@ -0,0 +1,70 @@
+package vulnerability
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
+	"github.com/tengattack/unified-ci/util"
+)
+
+// matchID reports whether the vulnerability has the ID, which is the last
+// part of the link or a word of the title
+func matchID(d vulcommon.Data, id string) bool {
+	if d.Link != "" && strings.EqualFold(path.Base(d.Link), id) {
+		return true
+	}
+	re := regexp.MustCompile(`(?i)(^|[^\w-])` + regexp.QuoteMeta(id) + `($|[^\w-])`)
+	return re.MatchString(d.VulTitle)
+}
+
+// match reports whether the vulnerability is ignored by i
+func match(d vulcommon.Data, i util.VulnerabilityIgnore) bool {
+	if i.Package != "" && i.Package != d.Name {
+		return false
+	}
+	return i.ID == "" || matchID(d, i.ID)
+}
+
+// Suppress marks the vulnerabilities ignored by the project, the ones with
+// an expired suppression are marked as expired, unless another suppression
+// still ignores them.
+func Suppress(data []vulcommon.Data, ignore []util.VulnerabilityIgnore, now time.Time) []vulcommon.Data {
+	if len(ignore) == 0 {
+		return data
+	}
+	result := make([]vulcommon.Data, len(data))
+	for n, d := range data {
+		d.Suppression = nil
+		for _, i := range ignore {
+			if !match(d, i) {
+				continue
+			}
+			s := &vulcommon.Suppression{
+				Reason:  i.Reason,
+				Until:   i.Until,
+				Expired: i.Expired(now),
+			}
+			if d.Suppression == nil || d.Suppression.Expired && !s.Expired {
+				d.Suppression = s
+			}
+		}
+		result[n] = d
+	}
+	return result
+}
+
+// Partition splits the vulnerabilities into the failing ones, including the
+// ones whose suppressions have expired, and the suppressed ones
+func Partition(data []vulcommon.Data) (failing, suppressed []vulcommon.Data) {
+	for _, d := range data {
+		if d.Suppressed() {
+			suppressed = append(suppressed, d)
+		} else {
+			failing = append(failing, d)
+		}
+	}
+	return
+}
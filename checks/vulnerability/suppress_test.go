@@ -0,0 +1,53 @@
+package vulnerability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
+	"github.com/tengattack/unified-ci/util"
+)
+
+func TestSuppress(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []vulcommon.Data{
+		{Link: "https://nvd.nist.gov/vuln/detail/CVE-2020-8203", Name: "lodash", VulTitle: "CVE-2020-8203: Prototype pollution"},
+		{Link: "https://osv.dev/vulnerability/GHSA-3c6g-xr6g-9r3f", Name: "monolog/monolog", VulTitle: "GHSA-3c6g-xr6g-9r3f: Arbitrary file read"},
+		{Name: "express", VulTitle: "Open redirect (CVE-2024-29041)"},
+		{Name: "express", VulTitle: "Open redirect (CVE-2024-290410)"},
+	}
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.Local)
+	result := Suppress(data, []util.VulnerabilityIgnore{
+		{ID: "cve-2020-8203", Reason: "not reachable", Until: "2021-06-30"},
+		{Package: "monolog/monolog", Reason: "dev only"},
+		{ID: "CVE-2024-29041", Package: "express", Reason: "no redirects", Until: "2021-07-01"},
+	}, now)
+	assert.Nil(data[0].Suppression)
+	assert.Equal(&vulcommon.Suppression{Reason: "not reachable", Until: "2021-06-30", Expired: true}, result[0].Suppression)
+	assert.Equal(&vulcommon.Suppression{Reason: "dev only"}, result[1].Suppression)
+	assert.Equal(&vulcommon.Suppression{Reason: "no redirects", Until: "2021-07-01"}, result[2].Suppression)
+	assert.Nil(result[3].Suppression)
+
+	failing, suppressed := Partition(result)
+	assert.Equal([]vulcommon.Data{result[0], result[3]}, failing)
+	assert.Equal([]vulcommon.Data{result[1], result[2]}, suppressed)
+
+	assert.Equal("||lodash||CVE-2020-8203: Prototype pollution<br>**suppression expired on 2021-06-30**: not reachable|\n",
+		result[0].MDTableRow())
+	assert.Equal("||monolog/monolog||GHSA-3c6g-xr6g-9r3f: Arbitrary file read<br>suppressed: dev only|\n",
+		result[1].MDTableRow())
+	assert.Equal("||express||Open redirect (CVE-2024-29041)<br>suppressed until 2021-07-01: no redirects|\n",
+		result[2].MDTableRow())
+
+	// a suppression still in effect wins over the expired one
+	result = Suppress(data[:1], []util.VulnerabilityIgnore{
+		{ID: "CVE-2020-8203", Reason: "old", Until: "2021-01-01"},
+		{Package: "lodash", Reason: "new", Until: "2021-12-31"},
+	}, now)
+	assert.Equal("new", result[0].Suppression.Reason)
+	assert.True(result[0].Suppressed())
+
+	assert.Equal(data, Suppress(data, nil, now))
+}
@@ -1,9 +1,14 @@
 package vulnerability
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
+	"time"
 
+	"github.com/tengattack/unified-ci/checks/dependency"
 	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
+	"github.com/tengattack/unified-ci/checks/vulnerability/osv"
 	"github.com/tengattack/unified-ci/checks/vulnerability/riki"
 	"github.com/tengattack/unified-ci/common"
 	"github.com/tengattack/unified-ci/config"
@@ -12,64 +17,141 @@ import (
 
 // VulScanner interface
 type VulScanner interface {
-	CheckPackages(lang vulcommon.Language, pkgFilePath string) (bool, error)
-	Query(lang vulcommon.Language) ([]vulcommon.Data, error)
-	WaitForQuery()
+	// CheckPackages submits the package file to be scanned, it returns false
+	// if the language is not supported or there is no package
+	CheckPackages(ctx context.Context, lang vulcommon.Language, pkgFilePath string) (bool, error)
+	// Query returns vulcommon.ErrNotReady until the scanning of lang is finished
+	Query(ctx context.Context, lang vulcommon.Language) ([]vulcommon.Data, error)
 
 	// context
 	SetCommitID(commitID string)
 	SetContext(context string)
 }
 
-// NewScanner creates new vulnerability scanner
-func NewScanner(appName string, conf config.SectionVulnerability) VulScanner {
+// NewScanner creates new vulnerability scanner of the provider
+func NewScanner(appName string, conf config.SectionVulnerability) (VulScanner, error) {
+	switch conf.Provider {
+	case "osv":
+		return &osv.Scanner{
+			AppName: conf.AppNamePrefix + appName,
+			Path:    conf.OSVPath,
+		}, nil
+	}
+	client, err := common.NewHTTPClient(requestTimeout)
+	if err != nil {
+		return nil, err
+	}
 	return &riki.Scanner{
 		AppName: conf.AppNamePrefix + appName,
 		AppFrom: conf.AppFrom,
-	}
+		Client:  client,
+	}, nil
 }
 
-// CheckVulnerability checks the package vulnerability of repo
-func CheckVulnerability(projectName, repoPath, commitID, context string) (result []vulcommon.Data, err error) {
-	var lang []vulcommon.Language
-	scanner := NewScanner(projectName, common.Conf.Vulnerability)
-	scanner.SetCommitID(commitID)
-	scanner.SetContext(context)
+// the backoff of polling the scanning results
+var (
+	requestTimeout  = 30 * time.Second
+	pollInterval    = 2 * time.Second
+	maxPollInterval = 30 * time.Second
+)
 
-	gomod := filepath.Join(repoPath, "go.sum")
-	if util.FileExists(gomod) {
-		_, err := scanner.CheckPackages(vulcommon.Golang, gomod)
-		if err != nil {
-			return nil, err
+// WaitForQuery polls the scanning result of lang with an exponential backoff,
+// until it is ready or ctx is done
+func WaitForQuery(ctx context.Context, scanner VulScanner, lang vulcommon.Language) ([]vulcommon.Data, error) {
+	interval := pollInterval
+	for {
+		data, err := scanner.Query(ctx, lang)
+		if err != vulcommon.ErrNotReady {
+			return data, err
 		}
-		lang = append(lang, vulcommon.Golang)
-	}
-	composer := filepath.Join(repoPath, "composer.lock")
-	if util.FileExists(composer) {
-		_, err := scanner.CheckPackages(vulcommon.PHP, composer)
-		if err != nil {
-			return nil, err
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for the result of %s: %v", lang, ctx.Err())
+		case <-timer.C:
+		}
+		interval *= 2
+		if interval > maxPollInterval {
+			interval = maxPollInterval
 		}
-		lang = append(lang, vulcommon.PHP)
 	}
-	nodePackage := filepath.Join(repoPath, "package.json")
-	if util.FileExists(nodePackage) {
-		_, err := scanner.CheckPackages(vulcommon.NodeJS, nodePackage)
-		if err != nil {
-			return nil, err
+}
+
+// packageFiles are the package files checked for each language in the order
+// of preference
+var packageFiles = []struct {
+	Lang  vulcommon.Language
+	Files []string
+}{
+	{vulcommon.Golang, []string{"go.sum"}},
+	{vulcommon.Java, []string{"pom.xml"}},
+	{vulcommon.PHP, []string{"composer.lock"}},
+	{vulcommon.NodeJS, []string{"package.json"}},
+	{vulcommon.Python, []string{"poetry.lock", "requirements.txt"}},
+	{vulcommon.Rust, []string{"Cargo.lock"}},
+	{vulcommon.Ruby, []string{"Gemfile.lock"}},
+}
+
+// PackageFiles returns the names of all the package files checked, with the
+// files read along with them like the lockfiles of package.json
+func PackageFiles() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, pkg := range packageFiles {
+		for _, file := range pkg.Files {
+			for _, name := range dependency.ReadFiles(file) {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
 		}
-		lang = append(lang, vulcommon.NodeJS)
+	}
+	return names
+}
+
+// CheckVulnerability checks the package vulnerability of repo, it waits for
+// the results until the configured query timeout
+func CheckVulnerability(ctx context.Context, projectName, repoPath, commitID, checkRef string) (result []vulcommon.Data, err error) {
+	var lang []vulcommon.Language
+	scanner, err := NewScanner(projectName, common.Conf.Vulnerability)
+	if err != nil {
+		return nil, err
+	}
+	scanner.SetCommitID(commitID)
+	scanner.SetContext(checkRef)
+
+	if timeout := common.Conf.Vulnerability.QueryTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	if len(lang) > 0 {
-		scanner.WaitForQuery()
-		for _, v := range lang {
-			data, err := scanner.Query(v)
+	for _, pkg := range packageFiles {
+		for _, name := range pkg.Files {
+			pkgFile := filepath.Join(repoPath, name)
+			if !util.FileExists(pkgFile) {
+				continue
+			}
+			ok, err := scanner.CheckPackages(ctx, pkg.Lang, pkgFile)
 			if err != nil {
 				return nil, err
 			}
-			result = append(result, data...)
+			if ok {
+				lang = append(lang, pkg.Lang)
+			}
+			// only the first found file of the language is checked
+			break
+		}
+	}
+
+	for _, v := range lang {
+		data, err := WaitForQuery(ctx, scanner, v)
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, data...)
 	}
 	return result, nil
 }
@@ -0,0 +1,69 @@
+package vulnerability
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vulcommon "github.com/tengattack/unified-ci/checks/vulnerability/common"
+)
+
+// pendingScanner is ready after the number of queries
+type pendingScanner struct {
+	pending int
+	queries int
+}
+
+func (s *pendingScanner) CheckPackages(ctx context.Context, lang vulcommon.Language, pkgFilePath string) (bool, error) {
+	return true, nil
+}
+
+func (s *pendingScanner) Query(ctx context.Context, lang vulcommon.Language) ([]vulcommon.Data, error) {
+	s.queries++
+	if s.queries <= s.pending {
+		return nil, vulcommon.ErrNotReady
+	}
+	return []vulcommon.Data{{Name: "fastjson"}}, nil
+}
+
+func (s *pendingScanner) SetCommitID(commitID string) {}
+
+func (s *pendingScanner) SetContext(context string) {}
+
+func TestWaitForQuery(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defer func(interval, max time.Duration) {
+		pollInterval, maxPollInterval = interval, max
+	}(pollInterval, maxPollInterval)
+	pollInterval, maxPollInterval = time.Millisecond, 4*time.Millisecond
+
+	scanner := &pendingScanner{pending: 4}
+	start := time.Now()
+	data, err := WaitForQuery(context.Background(), scanner, vulcommon.Java)
+	require.NoError(err)
+	assert.Equal([]vulcommon.Data{{Name: "fastjson"}}, data)
+	assert.Equal(5, scanner.queries)
+	// 1 + 2 + 4 + 4 ms
+	assert.True(time.Since(start) >= 11*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	scanner = &pendingScanner{pending: 1000}
+	_, err = WaitForQuery(ctx, scanner, vulcommon.Java)
+	assert.EqualError(err, "wait for the result of java: context deadline exceeded")
+	assert.True(scanner.queries > 1)
+}
+
+func TestPackageFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	files := PackageFiles()
+	for _, name := range []string{"go.sum", "go.mod", "package.json", "package-lock.json", "yarn.lock",
+		"composer.lock", "composer.json", "poetry.lock", "pyproject.toml"} {
+		assert.Contains(files, name)
+	}
+}
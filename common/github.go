@@ -37,6 +37,17 @@ type GithubRef struct {
 	CheckType string
 	CheckRef  string
 
+	// PRNum is the number of the checked pull request, 0 for branches
+	PRNum      int
+	BaseBranch string
+	HeadBranch string
+	Author     string
+	LogURL     string
+	// Env is the environment variables from the project config
+	Env map[string]string
+	// Secrets are the decrypted secrets of the repo, only provided to the tests
+	Secrets map[string]string
+
 	Owner    string
 	RepoName string
 
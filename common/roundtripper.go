@@ -162,6 +162,15 @@ func newProxyRoundTripper() (http.RoundTripper, error) {
 	return tr, nil
 }
 
+// NewHTTPClient creates a http client through the configured proxy
+func NewHTTPClient(timeout time.Duration) (*http.Client, error) {
+	tr, err := newProxyRoundTripper()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: tr, Timeout: timeout}, nil
+}
+
 type jwtRoundTripper struct {
 	transport http.RoundTripper
 	iss       int64
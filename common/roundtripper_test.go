@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,3 +43,19 @@ func TestNewProxyRoundTripper(t *testing.T) {
 	require.NoError(err)
 	assert.NotNil(tr)
 }
+
+func TestNewHTTPClient(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	Conf.Core.HTTPProxy = "http://127.0.0.1:3128"
+	defer func() { Conf.Core.HTTPProxy = "" }()
+	client, err := NewHTTPClient(time.Minute)
+	require.NoError(err)
+	assert.Equal(time.Minute, client.Timeout)
+	tr, ok := client.Transport.(*http.Transport)
+	require.True(ok)
+	u, err := tr.Proxy(httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+	require.NoError(err)
+	assert.Equal("127.0.0.1:3128", u.Host)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"io/ioutil"
 	"os"
+	"time"
 
 	mqredis "github.com/tengattack/unified-ci/mq/redis"
 	"gopkg.in/yaml.v2"
@@ -17,7 +18,11 @@ type Config struct {
 	Log           SectionLog           `yaml:"log"`
 	MessageQueue  SectionMessageQueue  `yaml:"mq"`
 	Vulnerability SectionVulnerability `yaml:"vulnerability"`
+	Registry      SectionRegistry      `yaml:"registry"`
 	Concurrency   SectionConcurrency   `yaml:"concurrency"`
+	Limits        SectionLimits        `yaml:"limits"`
+	Artifacts     SectionArtifacts     `yaml:"artifacts"`
+	Secrets       SectionSecrets       `yaml:"secrets"`
 }
 
 // SectionCore is a sub section of config.
@@ -30,6 +35,7 @@ type SectionCore struct {
 	DBFile        string `yaml:"db_file"`
 	WorkDir       string `yaml:"work_dir"`
 	LogsDir       string `yaml:"logs_dir"`
+	CacheDir      string `yaml:"cache_dir"`
 	CheckLogURI   string `yaml:"check_log_uri"`
 	GolangCILint  string `yaml:"golangcilint"`
 	RemarkLint    string `yaml:"remarklint"`
@@ -85,9 +91,28 @@ type SectionMessageQueue struct {
 
 // SectionVulnerability is a sub section of config.
 type SectionVulnerability struct {
-	Provider      string `yaml:"riki"`
+	// Provider is riki or osv
+	Provider      string `yaml:"provider"`
 	AppNamePrefix string `yaml:"app_name_prefix"`
 	AppFrom       string `yaml:"app_from"`
+	// OSVPath is the directory of the local OSV database mirror, of the JSON
+	// files or the zip exports, e.g. https://osv-vulnerabilities.storage.googleapis.com/Go/all.zip
+	OSVPath string `yaml:"osv_path"`
+	// QueryTimeout is how long to wait for the provider to finish the scanning
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+// SectionRegistry is a sub section of config.
+type SectionRegistry struct {
+	// GoProxyPath is the directory of the GOPROXY mirror, in the layout of
+	// $GOPATH/pkg/mod/cache/download
+	GoProxyPath string `yaml:"goproxy_path"`
+	// NpmPath is the directory of the npm metadata, of the packuments in
+	// "<name>/package.json" as the verdaccio storage
+	NpmPath string `yaml:"npm_path"`
+	// PackagistPath is the directory of the packagist mirror, of the
+	// metadata in "p2/<vendor>/<name>.json"
+	PackagistPath string `yaml:"packagist_path"`
 }
 
 // SectionConcurrency is a sub section of config.
@@ -97,6 +122,46 @@ type SectionConcurrency struct {
 	Test  int `yaml:"test"`
 }
 
+// SectionLimits is a sub section of config.
+type SectionLimits struct {
+	// Cgroup is the cgroup v2 directory delegated to the worker, e.g. /sys/fs/cgroup/unified-ci
+	Cgroup string `yaml:"cgroup"`
+	// Worker limits all the jobs on the worker together, requires cgroup
+	Worker ResourceLimits `yaml:"worker"`
+	// Test is the default and the upper bound of limits for each test
+	Test ResourceLimits `yaml:"test"`
+}
+
+// ResourceLimits limits the resources used by commands, zero means unlimited.
+type ResourceLimits struct {
+	Memory  string        `yaml:"memory"` // e.g. 512M, 2G
+	CPUTime time.Duration `yaml:"cpu_time"`
+	// OpenFiles is the RLIMIT_NOFILE of each process, exceeding it fails the
+	// opening silently rather than being reported as a violation
+	OpenFiles uint64 `yaml:"open_files"`
+	// Processes is the pids.max of the cgroup, without cgroup it falls back to
+	// RLIMIT_NPROC, which counts all the processes of the CI user including
+	// the other jobs, so that the forks may fail depending on them
+	Processes uint64 `yaml:"processes"`
+}
+
+// SectionArtifacts is a sub section of config.
+type SectionArtifacts struct {
+	// URI is the base url of the artifacts endpoint, e.g. http://example.com/artifacts/
+	URI       string        `yaml:"uri"`
+	Retention time.Duration `yaml:"retention"`
+	// MaxSize limits the total size of the artifacts of each test, e.g. 100M
+	MaxSize string `yaml:"max_size"`
+}
+
+// SectionSecrets is a sub section of config.
+type SectionSecrets struct {
+	// Key is the base64 encoded AES-256 key to encrypt the secrets of the repos
+	Key string `yaml:"key"`
+	// Token authorizes the requests managing the secrets, the API is disabled if empty
+	Token string `yaml:"token"`
+}
+
 // BuildDefaultConf is the default config setting.
 func BuildDefaultConf() Config {
 	var conf Config
@@ -110,6 +175,7 @@ func BuildDefaultConf() Config {
 	conf.Core.DBFile = "file.db"
 	conf.Core.WorkDir = "tmp"
 	conf.Core.LogsDir = "logs"
+	conf.Core.CacheDir = "cache"
 	conf.Core.CheckLogURI = ""
 	conf.Core.RemarkLint = "remark"
 	conf.Core.CPPLint = "cpplint"
@@ -153,14 +219,33 @@ func BuildDefaultConf() Config {
 	conf.MessageQueue.Redis.PoolSize = 10
 
 	// Vulnerability
-	conf.Vulnerability.Provider = "riki" // current support `riki` only
+	conf.Vulnerability.Provider = "riki"
 	conf.Vulnerability.AppNamePrefix = ""
 	conf.Vulnerability.AppFrom = ""
+	conf.Vulnerability.OSVPath = ""
+	conf.Vulnerability.QueryTimeout = 5 * time.Minute
+
+	// Registry
+	conf.Registry.GoProxyPath = ""
+	conf.Registry.NpmPath = ""
+	conf.Registry.PackagistPath = ""
 
 	// Concurrency
 	conf.Concurrency.Queue = 4
 	conf.Concurrency.Lint = 4
 	conf.Concurrency.Test = 1
+
+	// Limits
+	conf.Limits.Cgroup = ""
+
+	// Artifacts
+	conf.Artifacts.URI = ""
+	conf.Artifacts.Retention = 7 * 24 * time.Hour
+	conf.Artifacts.MaxSize = "100M"
+
+	// Secrets
+	conf.Secrets.Key = ""
+	conf.Secrets.Token = ""
 	return conf
 }
 
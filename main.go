@@ -16,6 +16,7 @@ import (
 	"github.com/tengattack/unified-ci/common"
 	"github.com/tengattack/unified-ci/config"
 	"github.com/tengattack/unified-ci/store"
+	"github.com/tengattack/unified-ci/util"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -79,6 +80,11 @@ func main() {
 	}
 	common.LogAccess.Infof("Working in %s mode", checker.WorkingMode)
 
+	if err = util.InitResourceLimits(conf.Limits); err != nil {
+		common.LogError.Errorf("init resource limits error: %v", err)
+		// PASS
+	}
+
 	if err = common.InitJWTClient(conf.GitHub.AppID, conf.GitHub.PrivateKey); err != nil {
 		log.Fatalf("error: %v", err)
 	}
@@ -117,6 +123,9 @@ func main() {
 				// Run local repo watcher
 				return worker.WatchLocalRepo(ctx)
 			})
+			g.Go(func() error {
+				return worker.CleanExpiredArtifacts(ctx)
+			})
 		case worker.ModeServer:
 			if common.Conf.Core.EnableRetries {
 				g.Go(func() error {
@@ -135,6 +144,9 @@ func main() {
 				checker.StartWorkerMessageSubscription(ctx)
 				return nil
 			})
+			g.Go(func() error {
+				return worker.CleanExpiredArtifacts(ctx)
+			})
 		}
 
 		g.Go(func() error {
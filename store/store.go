@@ -23,8 +23,30 @@ type CommitsInfo struct {
 	CreateTime int64    `db:"create_time"`
 }
 
+// CommitsSize struct
+type CommitsSize struct {
+	Owner      string `db:"owner"`
+	Repo       string `db:"repo"`
+	Sha        string `db:"sha"`
+	Name       string `db:"name"`
+	Size       int64  `db:"size"`
+	CreateTime int64  `db:"create_time"`
+}
+
+// RepoSecret struct
+type RepoSecret struct {
+	Owner string `db:"owner"`
+	Repo  string `db:"repo"`
+	Name  string `db:"name"`
+	// Value is the encrypted value of the secret
+	Value      []byte `db:"value"`
+	UpdateTime int64  `db:"update_time"`
+}
+
 var (
 	rwCommitsInfo = new(sync.RWMutex)
+	rwCommitsSize = new(sync.RWMutex)
+	rwRepoSecret  = new(sync.RWMutex)
 	db            *sqlx.DB
 )
 
@@ -77,6 +99,31 @@ func Init(file string) (err error) {
 		db.Close()
 		return err
 	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS commits_sizes (
+		owner TEXT NOT NULL DEFAULT '',
+		repo TEXT NOT NULL DEFAULT '',
+		sha TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		size INT NOT NULL DEFAULT '0',
+		create_time INT NOT NULL,
+		UNIQUE (owner, repo, sha, name)
+	)`)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS repos_secrets (
+		owner TEXT NOT NULL DEFAULT '',
+		repo TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL DEFAULT '',
+		value BLOB NOT NULL,
+		update_time INT NOT NULL,
+		UNIQUE (owner, repo, name)
+	)`)
+	if err != nil {
+		db.Close()
+		return err
+	}
 	return nil
 }
 
@@ -165,3 +212,74 @@ func ListCommitsInfo(owner, repo, sha string) ([]CommitsInfo, error) {
 	}
 	return c, nil
 }
+
+// Save to db
+func (c *CommitsSize) Save() error {
+	rwCommitsSize.Lock()
+	defer rwCommitsSize.Unlock()
+	t := time.Now().Unix()
+	_, err := db.Exec("INSERT OR REPLACE INTO commits_sizes (owner, repo, sha, name, size, create_time) VALUES (?, ?, ?, ?, ?, ?)",
+		c.Owner, c.Repo, c.Sha, c.Name, c.Size, t)
+	if err != nil {
+		return err
+	}
+	c.CreateTime = t
+	return nil
+}
+
+// ListCommitsSizes lists CommitsSizes by owner, repo and sha
+func ListCommitsSizes(owner, repo, sha string) ([]CommitsSize, error) {
+	rwCommitsSize.RLock()
+	defer rwCommitsSize.RUnlock()
+	var c []CommitsSize
+	err := db.Select(&c, "SELECT * FROM commits_sizes WHERE owner = ? AND repo = ? AND sha = ? ORDER BY name",
+		owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save to db
+func (s *RepoSecret) Save() error {
+	rwRepoSecret.Lock()
+	defer rwRepoSecret.Unlock()
+	t := time.Now().Unix()
+	_, err := db.Exec("INSERT OR REPLACE INTO repos_secrets (owner, repo, name, value, update_time) VALUES (?, ?, ?, ?, ?)",
+		s.Owner, s.Repo, s.Name, s.Value, t)
+	if err != nil {
+		return err
+	}
+	s.UpdateTime = t
+	return nil
+}
+
+// DeleteRepoSecret deletes a RepoSecret by owner, repo and name, it reports
+// whether the secret existed
+func DeleteRepoSecret(owner, repo, name string) (bool, error) {
+	rwRepoSecret.Lock()
+	defer rwRepoSecret.Unlock()
+	res, err := db.Exec("DELETE FROM repos_secrets WHERE owner = ? AND repo = ? AND name = ?",
+		owner, repo, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListRepoSecrets lists RepoSecrets by owner and repo
+func ListRepoSecrets(owner, repo string) ([]RepoSecret, error) {
+	rwRepoSecret.RLock()
+	defer rwRepoSecret.RUnlock()
+	var s []RepoSecret
+	err := db.Select(&s, "SELECT * FROM repos_secrets WHERE owner = ? AND repo = ? ORDER BY name",
+		owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
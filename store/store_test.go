@@ -123,3 +123,66 @@ func TestListCommitsInfo(t *testing.T) {
 	assert.NoError(err)
 	assert.Empty(c)
 }
+
+func TestSaveCommitsSize(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fileDB := "file size.db"
+	require.NoError(Init(fileDB))
+	defer os.Remove(fileDB)
+	defer Deinit()
+
+	s1 := &CommitsSize{Owner: "owner", Repo: "repo", Sha: "sha", Name: "app.js", Size: 1024}
+	require.NoError(s1.Save())
+	assert.NotEmpty(s1.CreateTime)
+	s2 := &CommitsSize{Owner: "owner", Repo: "repo", Sha: "sha", Name: "app.apk", Size: 4096}
+	require.NoError(s2.Save())
+
+	s1.Size = 2048
+	require.NoError(s1.Save())
+
+	sizes, err := ListCommitsSizes("owner", "repo", "sha")
+	require.NoError(err)
+	assert.Equal([]CommitsSize{*s2, *s1}, sizes)
+
+	sizes, err = ListCommitsSizes("owner", "repo", "other")
+	require.NoError(err)
+	assert.Empty(sizes)
+}
+
+func TestRepoSecret(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fileDB := "file secret.db"
+	require.NoError(Init(fileDB))
+	defer os.Remove(fileDB)
+	defer Deinit()
+
+	s1 := &RepoSecret{Owner: "owner", Repo: "repo", Name: "TOKEN", Value: []byte{1, 2, 3}}
+	require.NoError(s1.Save())
+	assert.NotEmpty(s1.UpdateTime)
+	s2 := &RepoSecret{Owner: "owner", Repo: "repo", Name: "API_KEY", Value: []byte{4, 5}}
+	require.NoError(s2.Save())
+	s3 := &RepoSecret{Owner: "owner", Repo: "other", Name: "TOKEN", Value: []byte{6}}
+	require.NoError(s3.Save())
+
+	s1.Value = []byte{7, 8, 9}
+	require.NoError(s1.Save())
+
+	secrets, err := ListRepoSecrets("owner", "repo")
+	require.NoError(err)
+	assert.Equal([]RepoSecret{*s2, *s1}, secrets)
+
+	deleted, err := DeleteRepoSecret("owner", "repo", "API_KEY")
+	require.NoError(err)
+	assert.True(deleted)
+	deleted, err = DeleteRepoSecret("owner", "repo", "API_KEY")
+	require.NoError(err)
+	assert.False(deleted)
+
+	secrets, err = ListRepoSecrets("owner", "repo")
+	require.NoError(err)
+	assert.Equal([]RepoSecret{*s1}, secrets)
+}
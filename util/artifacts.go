@@ -0,0 +1,154 @@
+package util
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var artifactNameRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ArtifactsName returns the archive file name of the test artifacts
+func ArtifactsName(testName string) string {
+	name := strings.Trim(artifactNameRegexp.ReplaceAllString(testName, "_"), "_.")
+	if name == "" {
+		name = "test"
+	}
+	return name + ".zip"
+}
+
+// ArtifactsDir returns the directory of the artifacts of a commit, next to its log
+func ArtifactsDir(logsDir, owner, repo, sha string) string {
+	return filepath.Join(logsDir, owner, repo, sha+".artifacts")
+}
+
+// ArtifactsURL returns the download url of the artifacts archive
+func ArtifactsURL(uri, owner, repo, sha, name string) string {
+	if uri == "" {
+		return ""
+	}
+	if !strings.HasSuffix(uri, "/") {
+		uri += "/"
+	}
+	return uri + owner + "/" + repo + "/" + sha + "/" + url.PathEscape(name)
+}
+
+// ArchiveArtifacts archives the files in repoPath matched by the patterns into
+// dest as a zip file, it returns the number of archived files. The archive will
+// not be created if no file matches.
+func ArchiveArtifacts(repoPath string, patterns []string, dest string, maxSize uint64) (int, error) {
+	var files []string
+	var size uint64
+	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || !MatchAny(patterns, rel) {
+			return nil
+		}
+		size += uint64(info.Size())
+		if maxSize > 0 && size > maxSize {
+			return fmt.Errorf("artifacts exceed the size limit of %d bytes", maxSize)
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil || len(files) == 0 {
+		return 0, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return 0, err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), ".artifacts-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := zip.NewWriter(tmp)
+	for _, rel := range files {
+		if err = addZipFile(w, filepath.Join(repoPath, filepath.FromSlash(rel)), rel); err != nil {
+			break
+		}
+	}
+	if erro := w.Close(); err == nil {
+		err = erro
+	}
+	if erro := tmp.Close(); err == nil {
+		err = erro
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(files), os.Rename(tmp.Name(), dest)
+}
+
+func addZipFile(w *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	fw, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, f)
+	return err
+}
+
+// ArtifactsExpired checks if the artifacts modified at modTime are out of retention
+func ArtifactsExpired(modTime time.Time, retention time.Duration) bool {
+	return retention > 0 && time.Since(modTime) > retention
+}
+
+// CleanExpiredArtifacts removes the artifacts directories out of retention in logsDir
+func CleanExpiredArtifacts(logsDir string, retention time.Duration) (removed int, err error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	dirs, err := filepath.Glob(filepath.Join(logsDir, "*", "*", "*.artifacts"))
+	if err != nil {
+		return 0, err
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() || !ArtifactsExpired(info.ModTime(), retention) {
+			continue
+		}
+		if err = os.RemoveAll(dir); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactsName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("unit.zip", ArtifactsName("unit"))
+	assert.Equal("go_mysql_1.13.zip", ArtifactsName("go (mysql, 1.13)"))
+	assert.Equal("test.zip", ArtifactsName("../"))
+	assert.Equal("http://example.com/artifacts/owner/repo/sha/go_1.13.zip",
+		ArtifactsURL("http://example.com/artifacts", "owner", "repo", "sha", "go_1.13.zip"))
+	assert.Empty(ArtifactsURL("", "owner", "repo", "sha", "unit.zip"))
+}
+
+func TestArchiveArtifacts(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+	logsDir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(logsDir)
+
+	require.NoError(os.MkdirAll(filepath.Join(repoPath, "dist", "js"), 0755))
+	require.NoError(os.MkdirAll(filepath.Join(repoPath, ".git"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "dist", "js", "app.js"), []byte("app"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "coverage.html"), []byte("<html>"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "main.go"), []byte("package main"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, ".git", "HEAD"), []byte("ref"), 0644))
+
+	dest := filepath.Join(ArtifactsDir(logsDir, "owner", "repo", "sha"), "unit.zip")
+	n, err := ArchiveArtifacts(repoPath, []string{"dist/**", "*.html", ".git/**"}, dest, 0)
+	require.NoError(err)
+	assert.Equal(2, n)
+
+	r, err := zip.OpenReader(dest)
+	require.NoError(err)
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	r.Close()
+	sort.Strings(names)
+	assert.Equal([]string{"coverage.html", "dist/js/app.js"}, names)
+
+	_, err = ArchiveArtifacts(repoPath, []string{"**"}, dest, 4)
+	assert.Error(err)
+
+	n, err = ArchiveArtifacts(repoPath, []string{"*.txt"}, filepath.Join(filepath.Dir(dest), "none.zip"), 0)
+	require.NoError(err)
+	assert.Zero(n)
+	_, err = os.Stat(filepath.Join(filepath.Dir(dest), "none.zip"))
+	assert.True(os.IsNotExist(err))
+
+	removed, err := CleanExpiredArtifacts(logsDir, time.Hour)
+	require.NoError(err)
+	assert.Zero(removed)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(os.Chtimes(filepath.Dir(dest), old, old))
+	removed, err = CleanExpiredArtifacts(logsDir, time.Hour)
+	require.NoError(err)
+	assert.Equal(1, removed)
+	_, err = os.Stat(filepath.Dir(dest))
+	assert.True(os.IsNotExist(err))
+}
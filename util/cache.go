@@ -0,0 +1,311 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxCachesPerRepo is the number of the latest caches to keep for each repo
+const maxCachesPerRepo = 5
+
+// CacheConfig config for the dependency cache of the repo
+type CacheConfig struct {
+	// Paths are the directories relative to the repo root to cache, e.g. node_modules
+	Paths []string `yaml:"paths"`
+	// KeyFiles are the files whose content keys the cache, e.g. go.sum, package-lock.json
+	KeyFiles []string `yaml:"key_files"`
+}
+
+// Enabled reports whether there is anything to cache
+func (c CacheConfig) Enabled() bool {
+	return len(c.Paths) > 0
+}
+
+// Key returns the cache key hashed from the paths and the content of the key files
+func (c CacheConfig) Key(repoPath string) (string, error) {
+	h := sha256.New()
+	paths := append([]string(nil), c.Paths...)
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Fprintf(h, "path %s\n", filepath.ToSlash(filepath.Clean(p)))
+	}
+	keyFiles := append([]string(nil), c.KeyFiles...)
+	sort.Strings(keyFiles)
+	for _, name := range keyFiles {
+		path, ok := joinRepoPath(repoPath, name)
+		if !ok {
+			return "", fmt.Errorf("cache key file %q is outside of the repo", name)
+		}
+		content, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			fmt.Fprintf(h, "file %s missing\n", name)
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "file %s %d\n", name, len(content))
+		_, _ = h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c CacheConfig) dirs(repoPath string) ([]string, error) {
+	dirs := make([]string, 0, len(c.Paths))
+	for _, p := range c.Paths {
+		dir, ok := joinRepoPath(repoPath, p)
+		if !ok || filepath.Clean(dir) == filepath.Clean(repoPath) {
+			return nil, fmt.Errorf("cache path %q is outside of the repo", p)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+func cacheFile(cacheDir, owner, repo, key string) string {
+	return filepath.Join(cacheDir, owner, repo, key+".tar.gz")
+}
+
+// RestoreCache restores the cached directories of the key into the repo,
+// hit is false if there is no cache for the key.
+func RestoreCache(cacheDir, owner, repo, key string, c CacheConfig, repoPath string) (hit bool, err error) {
+	dirs, err := c.dirs(repoPath)
+	if err != nil {
+		return false, err
+	}
+	path := cacheFile(cacheDir, owner, repo, key)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+	// mark the cache as recently used
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	for _, dir := range dirs {
+		if err = os.RemoveAll(dir); err != nil {
+			return false, err
+		}
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return false, err
+	}
+	defer gz.Close()
+	err = extractTar(tar.NewReader(gz), repoPath, dirs)
+	return err == nil, err
+}
+
+// SaveCache saves the cache directories of the repo with the key, the older
+// caches of the repo will be removed.
+func SaveCache(cacheDir, owner, repo, key string, c CacheConfig, repoPath string) error {
+	dirs, err := c.dirs(repoPath)
+	if err != nil {
+		return err
+	}
+	path := cacheFile(cacheDir, owner, repo, key)
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".cache-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	for _, dir := range dirs {
+		if err = addTarDir(tw, repoPath, dir); err != nil {
+			break
+		}
+	}
+	if erro := tw.Close(); err == nil {
+		err = erro
+	}
+	if erro := gz.Close(); err == nil {
+		err = erro
+	}
+	if erro := tmp.Close(); err == nil {
+		err = erro
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return pruneCaches(filepath.Dir(path), maxCachesPerRepo)
+}
+
+func addTarDir(tw *tar.Writer, repoPath, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				// nothing to cache
+				return nil
+			}
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+func extractTar(tr *tar.Reader, repoPath string, dirs []string) error {
+	realRepoPath, err := filepath.EvalSymlinks(repoPath)
+	if err != nil {
+		return err
+	}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		target, ok := joinRepoPath(repoPath, filepath.FromSlash(header.Name))
+		if !ok || !inDirs(target, dirs) {
+			return fmt.Errorf("invalid cache entry %q", header.Name)
+		}
+		// the parent must not be redirected out of the repo by a symlink
+		if ok, err = realPathInRepo(realRepoPath, filepath.Dir(target)); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("invalid cache entry %q", header.Name)
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, mode|0700)
+		case tar.TypeSymlink:
+			if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err == nil {
+				err = os.Symlink(header.Linkname, target)
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err == nil {
+				err = writeFile(target, tr, mode)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func inDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPathInRepo checks if the nearest existing ancestor of path resolves into the repo
+func realPathInRepo(realRepoPath, path string) (bool, error) {
+	for {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			rel, err := filepath.Rel(realRepoPath, real)
+			if err != nil {
+				return false, nil
+			}
+			_, ok := joinRepoPath(realRepoPath, rel)
+			return ok, nil
+		}
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false, nil
+		}
+		path = parent
+	}
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if erro := f.Close(); err == nil {
+		err = erro
+	}
+	return err
+}
+
+// pruneCaches keeps the latest used caches in dir
+func pruneCaches(dir string, keep int) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var caches []os.FileInfo
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".tar.gz") {
+			caches = append(caches, f)
+		}
+	}
+	if len(caches) <= keep {
+		return nil
+	}
+	sort.Slice(caches, func(i, j int) bool {
+		return caches[i].ModTime().After(caches[j].ModTime())
+	})
+	var errs []string
+	for _, f := range caches[keep:] {
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+	cacheDir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(cacheDir)
+
+	cache := CacheConfig{
+		Paths:    []string{"node_modules"},
+		KeyFiles: []string{"package-lock.json"},
+	}
+	assert.True(cache.Enabled())
+	assert.False(CacheConfig{}.Enabled())
+
+	key, err := cache.Key(repoPath)
+	require.NoError(err)
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "package-lock.json"), []byte("{}"), 0644))
+	key2, err := cache.Key(repoPath)
+	require.NoError(err)
+	assert.NotEqual(key, key2)
+	key = key2
+
+	hit, err := RestoreCache(cacheDir, "owner", "repo", key, cache, repoPath)
+	require.NoError(err)
+	assert.False(hit)
+
+	modules := filepath.Join(repoPath, "node_modules")
+	require.NoError(os.MkdirAll(filepath.Join(modules, "pkg", "bin"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(modules, "pkg", "bin", "cli"), []byte("#!/bin/sh"), 0755))
+	require.NoError(os.Symlink("../pkg/bin/cli", filepath.Join(modules, "pkg", "cli")))
+	require.NoError(SaveCache(cacheDir, "owner", "repo", key, cache, repoPath))
+
+	require.NoError(os.RemoveAll(modules))
+	require.NoError(os.MkdirAll(filepath.Join(modules, "stale"), 0755))
+	hit, err = RestoreCache(cacheDir, "owner", "repo", key, cache, repoPath)
+	require.NoError(err)
+	assert.True(hit)
+	content, err := ioutil.ReadFile(filepath.Join(modules, "pkg", "cli"))
+	require.NoError(err)
+	assert.Equal("#!/bin/sh", string(content))
+	info, err := os.Stat(filepath.Join(modules, "pkg", "bin", "cli"))
+	require.NoError(err)
+	assert.Equal(os.FileMode(0755), info.Mode().Perm())
+	_, err = os.Stat(filepath.Join(modules, "stale"))
+	assert.True(os.IsNotExist(err))
+
+	_, err = RestoreCache(cacheDir, "owner", "repo", key, CacheConfig{Paths: []string{"../outside"}}, repoPath)
+	assert.Error(err)
+	_, err = CacheConfig{KeyFiles: []string{"../go.sum"}}.Key(repoPath)
+	assert.Error(err)
+}
+
+func TestCacheEscape(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+	outside, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(outside)
+	cacheDir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(cacheDir)
+
+	// a crafted cache writing through a symlink out of the repo
+	path := cacheFile(cacheDir, "owner", "repo", "key")
+	require.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	f, err := os.Create(path)
+	require.NoError(err)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	require.NoError(tw.WriteHeader(&tar.Header{Name: "vendor/link", Typeflag: tar.TypeSymlink, Linkname: outside}))
+	require.NoError(tw.WriteHeader(&tar.Header{Name: "vendor/link/sub/evil", Typeflag: tar.TypeReg, Mode: 0644}))
+	require.NoError(tw.Close())
+	require.NoError(gz.Close())
+	require.NoError(f.Close())
+
+	_, err = RestoreCache(cacheDir, "owner", "repo", "key", CacheConfig{Paths: []string{"vendor"}}, repoPath)
+	assert.Error(err)
+	_, err = os.Stat(filepath.Join(outside, "sub"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestPruneCaches(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.tar.gz", "b.tar.gz", "c.tar.gz"} {
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	require.NoError(pruneCaches(dir, 3))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	assert.Len(files, 3)
+	require.NoError(pruneCaches(dir, 1))
+	files, err = ioutil.ReadDir(dir)
+	require.NoError(err)
+	assert.Len(files, 1)
+}
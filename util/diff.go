@@ -37,6 +37,29 @@ func GetTrimmedNewName(d *diff.FileDiff) (string, bool) {
 	return newName, false
 }
 
+// ChangedFiles returns the trimmed names of the files changed in diffs,
+// both the original and the new names are included for renames
+func ChangedFiles(diffs []*diff.FileDiff) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(name, prefix string) {
+		name = Unquote(name)
+		if name == "/dev/null" {
+			return
+		}
+		name = strings.TrimPrefix(name, prefix)
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	for _, d := range diffs {
+		add(d.OrigName, "a/")
+		add(d.NewName, "b/")
+	}
+	return files
+}
+
 // GetNumberOfContextLines get the number of context lines
 func GetNumberOfContextLines(hunk *diff.Hunk, limit int) int {
 	if hunk == nil {
@@ -43,3 +43,15 @@ func TestGetTrimmedNewName(t *testing.T) {
 	assert.False(ok)
 	assert.Equal("name", name)
 }
+
+func TestChangedFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	files := ChangedFiles([]*diff.FileDiff{
+		{OrigName: "a/main.go", NewName: "b/main.go"},
+		{OrigName: "a/old.md", NewName: "b/docs/new.md"},
+		{OrigName: "/dev/null", NewName: "b/added.go"},
+		{OrigName: "a/deleted.go", NewName: "/dev/null"},
+	})
+	assert.Equal([]string{"main.go", "old.md", "docs/new.md", "added.go", "deleted.go"}, files)
+}
@@ -1,10 +1,14 @@
 package util
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/tengattack/unified-ci/common"
 )
@@ -42,3 +46,29 @@ func RunGitCommand(ref common.GithubRef, dir string, args []string, output io.Wr
 	cmd.Dir = dir
 	return cmd.Run()
 }
+
+// ExtractFiles writes the files of the commit into dir at their paths, the
+// files not in the commit are skipped. Only the git objects are read, so that
+// the changes in the working tree do not affect them.
+func ExtractFiles(ref common.GithubRef, repoPath, sha, dir string, names []string) error {
+	for _, name := range names {
+		object := sha + ":" + filepath.ToSlash(name)
+		if RunGitCommand(ref, repoPath, []string{"cat-file", "-e", object}, nil) != nil {
+			// not in the commit
+			continue
+		}
+		var content bytes.Buffer
+		err := RunGitCommand(ref, repoPath, []string{"cat-file", "blob", object}, &content)
+		if err != nil {
+			return fmt.Errorf("read %s failed: %v", object, err)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(path, content.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,8 +1,12 @@
 package util
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tengattack/unified-ci/common"
 )
@@ -14,3 +18,21 @@ func TestRunGitCommand(t *testing.T) {
 
 	require.NoError(RunGitCommand(common.GithubRef{}, ".", []string{"status"}, nil))
 }
+
+func TestExtractFiles(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	common.Conf.Core.GitCommand = "git"
+
+	dir, err := ioutil.TempDir("", "unified-ci-test-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	err = ExtractFiles(common.GithubRef{}, ".", "HEAD", dir, []string{"go.mod", "util/not-found"})
+	require.NoError(err)
+	content, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(err)
+	assert.Contains(string(content), "module github.com/tengattack/unified-ci")
+	assert.False(FileExists(filepath.Join(dir, "util", "not-found")))
+}
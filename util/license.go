@@ -0,0 +1,61 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LicenseConfig config for the license compliance check of the dependencies
+type LicenseConfig struct {
+	// Allow lists the SPDX license IDs allowed, all the licenses not denied
+	// are allowed if it is empty. A trailing "*" matches any suffix, e.g.
+	// "BSD-*" matches "BSD-2-Clause" and "BSD-3-Clause".
+	Allow []string `yaml:"allow"`
+	// Deny lists the SPDX license IDs denied, e.g. "GPL-*", "AGPL-*"
+	Deny []string `yaml:"deny"`
+}
+
+// Enabled reports whether there is any license to check
+func (c LicenseConfig) Enabled() bool {
+	return len(c.Allow) > 0 || len(c.Deny) > 0
+}
+
+// matchLicense reports whether the license ID matches any of the patterns,
+// case-insensitively
+func matchLicense(patterns []string, id string) bool {
+	id = strings.ToLower(id)
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(id, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if id == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckLicense checks the license ID against the lists, it returns the
+// reason if the license is not allowed
+func (c LicenseConfig) CheckLicense(id string) (bool, string) {
+	if matchLicense(c.Deny, id) {
+		return false, fmt.Sprintf("%s is denied", id)
+	}
+	if len(c.Allow) > 0 && !matchLicense(c.Allow, id) {
+		return false, fmt.Sprintf("%s is not allowed", id)
+	}
+	return true, ""
+}
+
+func (c LicenseConfig) validate() error {
+	for _, list := range [][]string{c.Allow, c.Deny} {
+		for _, p := range list {
+			if strings.TrimSpace(p) == "" || strings.Contains(strings.TrimSuffix(p, "*"), "*") {
+				return fmt.Errorf("invalid license pattern %q", p)
+			}
+		}
+	}
+	return nil
+}
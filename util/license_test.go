@@ -0,0 +1,33 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLicenseConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c := LicenseConfig{Deny: []string{"GPL-*", "AGPL-3.0"}}
+	assert.True(c.Enabled())
+	assert.NoError(c.validate())
+	ok, reason := c.CheckLicense("GPL-3.0-or-later")
+	assert.False(ok)
+	assert.Equal("GPL-3.0-or-later is denied", reason)
+	ok, _ = c.CheckLicense("agpl-3.0")
+	assert.False(ok)
+	ok, _ = c.CheckLicense("LGPL-2.1")
+	assert.True(ok)
+
+	c = LicenseConfig{Allow: []string{"MIT", "BSD-*"}}
+	ok, _ = c.CheckLicense("BSD-3-Clause")
+	assert.True(ok)
+	ok, reason = c.CheckLicense("MPL-2.0")
+	assert.False(ok)
+	assert.Equal("MPL-2.0 is not allowed", reason)
+
+	assert.False(LicenseConfig{}.Enabled())
+	assert.EqualError(LicenseConfig{Deny: []string{"*GPL*"}}.validate(), `invalid license pattern "*GPL*"`)
+	assert.EqualError(LicenseConfig{Allow: []string{" "}}.validate(), `invalid license pattern " "`)
+}
@@ -0,0 +1,235 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/tengattack/unified-ci/config"
+)
+
+// resource limit violations
+const (
+	ViolationMemory    = "killed: memory limit exceeded"
+	ViolationCPUTime   = "killed: CPU time limit exceeded"
+	ViolationProcesses = "killed: process limit exceeded"
+)
+
+var (
+	workerLimits config.SectionLimits
+	// workerCgroup is empty if cgroup v2 is not available
+	workerCgroup atomic.Value
+)
+
+// Sandbox applies the resource limits to the commands of a test
+type Sandbox struct {
+	limits   config.ResourceLimits
+	memory   uint64
+	cgroup   string
+	oomKills uint64
+	pidsMax  uint64
+}
+
+// ParseByteSize parses sizes like 512M, 2G or 1024 into bytes
+func ParseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	if s == "" {
+		return 0, errors.New("invalid size")
+	}
+	unit := uint64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		unit = 1 << 10
+	case 'M':
+		unit = 1 << 20
+	case 'G':
+		unit = 1 << 30
+	case 'T':
+		unit = 1 << 40
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
+
+func minLimit(a, b uint64) uint64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// MergeLimits returns the test limits filled with the defaults, and bounded by them
+func MergeLimits(test, defaults config.ResourceLimits) (config.ResourceLimits, error) {
+	merged := test
+	testMemory, err := ParseByteSize(test.Memory)
+	if err != nil {
+		return merged, err
+	}
+	defaultMemory, err := ParseByteSize(defaults.Memory)
+	if err != nil {
+		return merged, err
+	}
+	if m := minLimit(testMemory, defaultMemory); m > 0 {
+		merged.Memory = strconv.FormatUint(m, 10)
+	}
+	if merged.CPUTime <= 0 || (defaults.CPUTime > 0 && defaults.CPUTime < merged.CPUTime) {
+		merged.CPUTime = defaults.CPUTime
+	}
+	merged.OpenFiles = minLimit(test.OpenFiles, defaults.OpenFiles)
+	merged.Processes = minLimit(test.Processes, defaults.Processes)
+	return merged, nil
+}
+
+// InitResourceLimits sets up the worker limits, the cgroup will be ignored if it is not available
+func InitResourceLimits(conf config.SectionLimits) error {
+	workerLimits = conf
+	workerCgroup.Store("")
+	if conf.Cgroup == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(conf.Cgroup), "cgroup.controllers")); err != nil {
+		return fmt.Errorf("cgroup v2 is not available: %v", err)
+	}
+	if err := os.MkdirAll(conf.Cgroup, 0755); err != nil {
+		return err
+	}
+	if err := writeCgroupFile(conf.Cgroup, "cgroup.subtree_control", "+memory +pids"); err != nil {
+		return err
+	}
+	if err := writeCgroupLimits(conf.Cgroup, conf.Worker); err != nil {
+		return err
+	}
+	workerCgroup.Store(conf.Cgroup)
+	return nil
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	return ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0644)
+}
+
+func readCgroupEvent(dir, name, key string) uint64 {
+	content, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			n, _ := strconv.ParseUint(fields[1], 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+func writeCgroupLimits(dir string, limits config.ResourceLimits) error {
+	memory, err := ParseByteSize(limits.Memory)
+	if err != nil {
+		return err
+	}
+	if memory > 0 {
+		if err = writeCgroupFile(dir, "memory.max", strconv.FormatUint(memory, 10)); err != nil {
+			return err
+		}
+		// PASS: swap may not be enabled
+		_ = writeCgroupFile(dir, "memory.swap.max", "0")
+	}
+	if limits.Processes > 0 {
+		if err = writeCgroupFile(dir, "pids.max", strconv.FormatUint(limits.Processes, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSandbox creates a sandbox with the test limits merged with the worker defaults
+func NewSandbox(limits config.ResourceLimits) (*Sandbox, error) {
+	merged, err := MergeLimits(limits, workerLimits.Test)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sandbox{limits: merged}
+	s.memory, _ = ParseByteSize(merged.Memory)
+
+	parent, _ := workerCgroup.Load().(string)
+	if parent != "" {
+		dir := filepath.Join(parent, "test-"+uuid.NewV4().String())
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return nil, err
+		}
+		s.cgroup = dir
+		if err := writeCgroupLimits(dir, merged); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Limited reports whether there is any limit to apply
+func (s *Sandbox) Limited() bool {
+	if s == nil {
+		return false
+	}
+	return s.cgroup != "" || s.memory > 0 || s.limits.CPUTime > 0 ||
+		s.limits.OpenFiles > 0 || s.limits.Processes > 0
+}
+
+// Violation returns the violated limit after a command of the sandbox exits
+func (s *Sandbox) Violation(err error) string {
+	if s == nil {
+		return ""
+	}
+	if s.cgroup != "" {
+		if n := readCgroupEvent(s.cgroup, "memory.events", "oom_kill"); n > s.oomKills {
+			s.oomKills = n
+			return ViolationMemory
+		}
+		if n := readCgroupEvent(s.cgroup, "pids.events", "max"); n > s.pidsMax {
+			s.pidsMax = n
+			if err != nil {
+				return ViolationProcesses
+			}
+		}
+	}
+	if err != nil {
+		return s.signalViolation(err)
+	}
+	return ""
+}
+
+// Close kills the processes left in the sandbox and removes its cgroup
+func (s *Sandbox) Close() error {
+	if s == nil || s.cgroup == "" {
+		return nil
+	}
+	killCgroup(s.cgroup)
+	var err error
+	// wait for the killed processes to leave the cgroup
+	for i := 0; i < 20; i++ {
+		err = os.Remove(s.cgroup)
+		if err == nil {
+			s.cgroup = ""
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sandboxSupported = true
+
+	rlimitNproc = 6
+)
+
+func prlimit(pid int, resource int, cur, max uint64) error {
+	lim := syscall.Rlimit{Cur: cur, Max: max}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(&lim)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// apply moves the process into the sandbox and sets its rlimits
+func (s *Sandbox) apply(pid int) error {
+	if s.cgroup != "" {
+		err := writeCgroupFile(s.cgroup, "cgroup.procs", strconv.Itoa(pid))
+		if err != nil {
+			return err
+		}
+	} else if s.memory > 0 {
+		// fallback to limit the address space without cgroup
+		if err := prlimit(pid, syscall.RLIMIT_AS, s.memory, s.memory); err != nil {
+			return err
+		}
+	}
+	if s.limits.CPUTime > 0 {
+		seconds := uint64(s.limits.CPUTime.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		// SIGXCPU is sent at the soft limit, while the hard limit is kept 1s
+		// above it as the kernel sends SIGKILL if they are equal
+		if err := prlimit(pid, syscall.RLIMIT_CPU, seconds, seconds+1); err != nil {
+			return err
+		}
+	}
+	if s.limits.OpenFiles > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_NOFILE, s.limits.OpenFiles, s.limits.OpenFiles); err != nil {
+			return err
+		}
+	}
+	if s.cgroup == "" && s.limits.Processes > 0 {
+		// NOTE: it counts all the processes of the real UID, including the
+		// other jobs of the worker, pids.max of the cgroup is used if available
+		if err := prlimit(pid, rlimitNproc, s.limits.Processes, s.limits.Processes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sandbox) signalViolation(err error) string {
+	ee, ok := err.(*exec.ExitError)
+	if !ok {
+		return ""
+	}
+	ws, ok := ee.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+	switch ws.Signal() {
+	case syscall.SIGXCPU:
+		return ViolationCPUTime
+	case syscall.SIGKILL:
+		if s.limits.CPUTime > 0 && ee.UserTime()+ee.SystemTime() >= s.limits.CPUTime {
+			return ViolationCPUTime
+		}
+	case syscall.SIGSEGV, syscall.SIGBUS, syscall.SIGABRT:
+		// the allocations failed by RLIMIT_AS may crash the process without
+		// cgroup, which is not distinguished from the other crashes
+		if s.cgroup == "" && s.memory > 0 {
+			return "killed: " + ws.Signal().String()
+		}
+	}
+	return ""
+}
+
+func killCgroup(dir string) {
+	if writeCgroupFile(dir, "cgroup.kill", "1") == nil {
+		return
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Fields(string(content)) {
+		if pid, err := strconv.Atoi(line); err == nil {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
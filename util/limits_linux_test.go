@@ -0,0 +1,56 @@
+package util
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/config"
+)
+
+func TestSandbox(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	require.NoError(InitResourceLimits(config.SectionLimits{}))
+
+	sandbox, err := NewSandbox(config.ResourceLimits{OpenFiles: 64})
+	require.NoError(err)
+	defer sandbox.Close()
+	assert.True(sandbox.Limited())
+
+	cmd := CommandContext(context.Background(), "sh", "-c", "ulimit -n")
+	cmd.Sandbox = sandbox
+	out, err := cmd.Output()
+	require.NoError(err)
+	assert.Equal("64", strings.TrimSpace(string(out)))
+	assert.Empty(sandbox.Violation(err))
+
+	sandbox, err = NewSandbox(config.ResourceLimits{CPUTime: time.Second})
+	require.NoError(err)
+	defer sandbox.Close()
+
+	cmd = CommandContext(context.Background(), "sh", "-c", "while :; do :; done")
+	cmd.Sandbox = sandbox
+	err = cmd.Run()
+	require.Error(err)
+	assert.Equal(ViolationCPUTime, sandbox.Violation(err))
+
+	cmd = CommandContext(context.Background(), "command-not-found")
+	cmd.Sandbox = sandbox
+	assert.Error(cmd.Run())
+
+	sandbox, err = NewSandbox(config.ResourceLimits{Memory: "256M"})
+	require.NoError(err)
+	defer sandbox.Close()
+
+	// a crash without cgroup is not taken as exceeding the memory limit
+	cmd = CommandContext(context.Background(), "sh", "-c", "kill -SEGV $$")
+	cmd.Sandbox = sandbox
+	err = cmd.Run()
+	require.Error(err)
+	assert.Equal("killed: segmentation fault", sandbox.Violation(err))
+}
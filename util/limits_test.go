@@ -0,0 +1,63 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tengattack/unified-ci/config"
+)
+
+func TestParseByteSize(t *testing.T) {
+	assert := assert.New(t)
+
+	for s, n := range map[string]uint64{
+		"":      0,
+		"1024":  1024,
+		"512K":  512 << 10,
+		"512m":  512 << 20,
+		"2G":    2 << 30,
+		"2GiB":  2 << 30,
+		"1 TB":  1 << 40,
+		"100MB": 100 << 20,
+	} {
+		size, err := ParseByteSize(s)
+		assert.NoError(err, s)
+		assert.Equal(n, size, s)
+	}
+	_, err := ParseByteSize("G")
+	assert.Error(err)
+	_, err = ParseByteSize("1.5G")
+	assert.Error(err)
+}
+
+func TestMergeLimits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defaults := config.ResourceLimits{
+		Memory:    "1G",
+		CPUTime:   time.Minute,
+		OpenFiles: 1024,
+	}
+	merged, err := MergeLimits(config.ResourceLimits{
+		Memory:    "2G",
+		CPUTime:   time.Second,
+		Processes: 100,
+	}, defaults)
+	require.NoError(err)
+	assert.Equal(config.ResourceLimits{
+		Memory:    "1073741824",
+		CPUTime:   time.Second,
+		OpenFiles: 1024,
+		Processes: 100,
+	}, merged)
+
+	merged, err = MergeLimits(config.ResourceLimits{}, config.ResourceLimits{})
+	require.NoError(err)
+	assert.Equal(config.ResourceLimits{}, merged)
+
+	_, err = MergeLimits(config.ResourceLimits{Memory: "lots"}, defaults)
+	assert.Error(err)
+}
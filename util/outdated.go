@@ -0,0 +1,34 @@
+package util
+
+import "errors"
+
+// OutdatedConfig config for reporting the direct dependencies far behind
+// their latest versions in the registry mirrors
+type OutdatedConfig struct {
+	// Major reports the dependencies more than Major major versions behind,
+	// 0 not to compare the major versions
+	Major int64 `yaml:"major"`
+	// Minor reports the dependencies more than Minor minor versions behind
+	// in the same major version, 0 not to compare the minor versions
+	Minor int64 `yaml:"minor"`
+}
+
+// Enabled reports whether any of the versions is compared
+func (c OutdatedConfig) Enabled() bool {
+	return c.Major > 0 || c.Minor > 0
+}
+
+// Exceeds checks if the dependency is more versions behind than allowed
+func (c OutdatedConfig) Exceeds(majorsBehind, minorsBehind int64) bool {
+	if c.Major > 0 && majorsBehind > c.Major {
+		return true
+	}
+	return c.Minor > 0 && majorsBehind == 0 && minorsBehind > c.Minor
+}
+
+func (c OutdatedConfig) validate() error {
+	if c.Major < 0 || c.Minor < 0 {
+		return errors.New("the versions behind must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutdatedConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c := OutdatedConfig{Major: 1}
+	assert.True(c.Enabled())
+	assert.False(c.Exceeds(1, 0))
+	assert.True(c.Exceeds(2, 0))
+	assert.False(c.Exceeds(0, 10))
+
+	c = OutdatedConfig{Minor: 3}
+	assert.True(c.Exceeds(0, 4))
+	assert.False(c.Exceeds(0, 3))
+	assert.False(c.Exceeds(5, 0))
+	assert.False(c.Exceeds(1, 4))
+
+	assert.False(OutdatedConfig{}.Enabled())
+	assert.NoError(c.validate())
+	assert.EqualError(OutdatedConfig{Major: -1}.validate(), "the versions behind must not be negative")
+}
@@ -0,0 +1,302 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KillGracePeriod is the time between SIGTERM and SIGKILL when terminating a process group
+var KillGracePeriod = 10 * time.Second
+
+// Cmd is an exec.Cmd running in its own process group, the whole group is
+// terminated when the context is done or the command exits.
+type Cmd struct {
+	*exec.Cmd
+
+	// Sandbox limits the resources of the command if set
+	Sandbox *Sandbox
+
+	ctx context.Context
+	// grace is KillGracePeriod when the command started
+	grace      time.Duration
+	done       chan struct{}
+	watched    chan struct{}
+	copies     sync.WaitGroup
+	pipes      []*os.File
+	gateReader *os.File
+}
+
+// CommandContext is like exec.CommandContext, but kills the entire process tree
+// instead of only the direct child on cancellation.
+func CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
+	if ctx == nil {
+		panic("nil Context")
+	}
+	return &Cmd{
+		Cmd: exec.Command(name, arg...),
+		ctx: ctx,
+	}
+}
+
+// pipeWriter replaces a non-file writer with a pipe copied by ourselves,
+// so that Wait returns as soon as the process exits even if a stray
+// grandchild keeps the output open.
+func (c *Cmd) pipeWriter(w io.Writer) (io.Writer, error) {
+	if w == nil {
+		return nil, nil
+	}
+	if _, ok := w.(*os.File); ok {
+		return w, nil
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	c.pipes = append(c.pipes, pr)
+	c.copies.Add(1)
+	go func() {
+		defer c.copies.Done()
+		_, _ = io.Copy(w, pr)
+	}()
+	return pw, nil
+}
+
+func (c *Cmd) closePipes() {
+	for _, p := range c.pipes {
+		p.Close()
+	}
+}
+
+// Start starts the command in a new process group
+func (c *Cmd) Start() error {
+	if c.done != nil {
+		return errors.New("exec: already started")
+	}
+	select {
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	default:
+	}
+
+	setProcessGroup(c.Cmd)
+
+	var gate *os.File
+	if sandboxSupported && c.Sandbox.Limited() {
+		var err error
+		gate, err = c.gateSandbox()
+		if err != nil {
+			return err
+		}
+	}
+
+	var writers []*os.File
+	stdout, err := c.pipeWriter(c.Stdout)
+	if err != nil {
+		return err
+	}
+	if f, ok := stdout.(*os.File); ok && f != c.Stdout {
+		writers = append(writers, f)
+	}
+	stderr := stdout
+	if !interfaceEqual(c.Stderr, c.Stdout) {
+		stderr, err = c.pipeWriter(c.Stderr)
+		if err != nil {
+			closeFiles(writers)
+			c.closePipes()
+			return err
+		}
+		if f, ok := stderr.(*os.File); ok && f != c.Stderr {
+			writers = append(writers, f)
+		}
+	}
+	c.Stdout, c.Stderr = stdout, stderr
+
+	err = c.Cmd.Start()
+	// the child holds its own copies now
+	closeFiles(writers)
+	if c.gateReader != nil {
+		c.gateReader.Close()
+	}
+	if err != nil {
+		if gate != nil {
+			gate.Close()
+		}
+		c.closePipes()
+		return err
+	}
+
+	pid := c.Process.Pid
+	if gate != nil {
+		err = c.Sandbox.apply(pid)
+		if err != nil {
+			// the command will not be executed
+			_ = signalProcessGroup(pid, sigKill)
+			gate.Close()
+			_ = c.Cmd.Wait()
+			c.closePipes()
+			return fmt.Errorf("apply resource limits error: %v", err)
+		}
+		// let the command run
+		_, _ = gate.Write([]byte{'\n'})
+		gate.Close()
+	}
+
+	c.grace = KillGracePeriod
+	c.done = make(chan struct{})
+	c.watched = make(chan struct{})
+	go func() {
+		defer close(c.watched)
+		select {
+		case <-c.ctx.Done():
+			_ = signalProcessGroup(pid, sigTerm)
+			select {
+			case <-time.After(c.grace):
+				_ = signalProcessGroup(pid, sigKill)
+			case <-c.done:
+			}
+		case <-c.done:
+		}
+	}()
+	return nil
+}
+
+// gateSandbox makes the command wait in a shell until the sandbox is applied
+// to it, and returns the writer to release it.
+func (c *Cmd) gateSandbox() (*os.File, error) {
+	if filepath.Base(c.Path) == c.Path {
+		// command not found
+		if _, err := exec.LookPath(c.Path); err != nil {
+			return nil, err
+		}
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return nil, err
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	fd := strconv.Itoa(3 + len(c.ExtraFiles))
+	c.ExtraFiles = append(c.ExtraFiles, pr)
+	args := []string{"sh", "-c", "read _ <&" + fd + "; exec " + fd + "<&-; exec \"$@\"", "sh", c.Path}
+	c.Args = append(args, c.Args[1:]...)
+	c.Path = sh
+	c.gateReader = pr
+	return pw, nil
+}
+
+// Wait waits for the command to exit, and then terminates the processes left
+// in its process group
+func (c *Cmd) Wait() error {
+	if c.done == nil {
+		return errors.New("exec: not started")
+	}
+	err := c.Cmd.Wait()
+	close(c.done)
+	<-c.watched
+
+	TerminateProcessGroup(c.Process.Pid, c.grace)
+
+	copied := make(chan struct{})
+	go func() {
+		c.copies.Wait()
+		close(copied)
+	}()
+	select {
+	case <-copied:
+	case <-time.After(c.grace):
+		// the output is still held by a process which escaped the group
+	}
+	c.closePipes()
+
+	if err != nil && c.ctx.Err() != nil {
+		// keep the same behavior as exec.CommandContext
+		if _, ok := err.(*exec.ExitError); !ok {
+			err = c.ctx.Err()
+		}
+	}
+	return err
+}
+
+// Run starts the command and waits for it to complete
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	captureErr := c.Stderr == nil
+	if captureErr {
+		c.Stderr = &stderr
+	}
+	err := c.Run()
+	if err != nil && captureErr {
+		if ee, ok := err.(*exec.ExitError); ok {
+			ee.Stderr = stderr.Bytes()
+		}
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output and standard error
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var b bytes.Buffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run()
+	return b.Bytes(), err
+}
+
+// TerminateProcessGroup sends SIGTERM to the process group of pid if it is
+// still alive, and SIGKILL after the grace period.
+func TerminateProcessGroup(pid int, grace time.Duration) {
+	if !processGroupAlive(pid) {
+		return
+	}
+	_ = signalProcessGroup(pid, sigTerm)
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if !processGroupAlive(pid) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	_ = signalProcessGroup(pid, sigKill)
+}
+
+func interfaceEqual(a, b interface{}) bool {
+	defer func() {
+		_ = recover()
+	}()
+	return a == b
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
@@ -0,0 +1,18 @@
+package util
+
+import (
+	"sync"
+	"syscall"
+)
+
+const prSetChildSubreaper = 36
+
+var subreaperOnce sync.Once
+
+// becomeSubreaper makes the orphaned grandchildren reparent to us instead of
+// init, so that they can be reaped by processGroupAlive.
+func becomeSubreaper() {
+	subreaperOnce.Do(func() {
+		_, _, _ = syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0)
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package util
+
+func becomeSubreaper() {
+	// PASS
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+// +build !windows
+
+package util
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func processRunning(pid int) bool {
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) > 2 && fields[2] != "Z"
+}
+
+func TestCommandContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	gracePeriod := KillGracePeriod
+	KillGracePeriod = time.Second
+	defer func() { KillGracePeriod = gracePeriod }()
+
+	// cancelled: the grandchild holding the output should be killed too
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var out strings.Builder
+	cmd := CommandContext(ctx, "sh", "-c", "sleep 100 & echo $!; wait")
+	cmd.Stdout = &out
+	start := time.Now()
+	err := cmd.Run()
+	assert.Error(err)
+	assert.True(time.Since(start) < 5*time.Second)
+	pid, err := strconv.Atoi(strings.TrimSpace(out.String()))
+	require.NoError(err)
+	assert.False(processRunning(pid))
+
+	// exited: the stray background process should be killed
+	cmd = CommandContext(context.Background(), "sh", "-c", "sleep 100 & echo $!")
+	start = time.Now()
+	output, err := cmd.Output()
+	require.NoError(err)
+	assert.True(time.Since(start) < 5*time.Second)
+	pid, err = strconv.Atoi(strings.TrimSpace(string(output)))
+	require.NoError(err)
+	assert.False(processRunning(pid))
+
+	output, err = CommandContext(context.Background(), "sh", "-c", "echo out; echo err >&2").CombinedOutput()
+	require.NoError(err)
+	assert.Equal("out\nerr\n", string(output))
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	sigTerm = syscall.SIGTERM
+	sigKill = syscall.SIGKILL
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	// PASS
+}
+
+func signalProcessGroup(pid int, sig syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+func processGroupAlive(pid int) bool {
+	return false
+}
@@ -1,10 +1,17 @@
 package util
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/tengattack/unified-ci/config"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -15,8 +22,38 @@ const (
 // ProjectConfig CI config for project
 type ProjectConfig struct {
 	LinterAfterTests bool                   `yaml:"linterAfterTests"`
+	Stages           []string               `yaml:"stages"`
 	Tests            map[string]TestsConfig `yaml:"tests"`
 	IgnorePatterns   []string               `yaml:"ignorePatterns"`
+	Cache            CacheConfig            `yaml:"cache"`
+	Benchmarks       BenchmarksConfig       `yaml:"benchmarks"`
+	Size             SizeConfig             `yaml:"size"`
+	Vulnerability    VulnerabilityConfig    `yaml:"vulnerability"`
+	License          LicenseConfig          `yaml:"license"`
+	Outdated         OutdatedConfig         `yaml:"outdated"`
+	SecretScan       SecretScanConfig       `yaml:"secret_scan"`
+	Security         SecurityConfig         `yaml:"security"`
+	// Env is the environment variables of all the tests and linters
+	Env map[string]string `yaml:"env"`
+}
+
+// BenchmarksConfig config for comparing the benchmarks of pull requests with base
+type BenchmarksConfig struct {
+	// TestsConfig is how to run the cmds, which print the results in the Go
+	// benchmark format, e.g. "go test -run=^$ -bench=. -count=5 ./..."
+	TestsConfig `yaml:",inline"`
+	// Threshold is the percent of a significant slowdown to fail the check
+	Threshold float64 `yaml:"threshold"`
+}
+
+// Enabled reports whether there is any benchmark to run
+func (c BenchmarksConfig) Enabled() bool {
+	for _, cmd := range c.Commands() {
+		if cmd != "" {
+			return true
+		}
+	}
+	return false
 }
 
 type projectConfigRaw struct {
@@ -30,6 +67,199 @@ type TestsConfig struct {
 	Coverage      string   `yaml:"coverage"`
 	DeltaCoverage string   `yaml:"delta_coverage"`
 	Cmds          []string `yaml:"cmds"`
+	// Script is a multi-line script run after the cmds in the shell
+	Script string `yaml:"script"`
+	// Shell runs the cmds and the script in bash or sh with fail-fast semantics,
+	// or none to run the cmds directly. By default the cmds are run directly
+	// and only the script is run in sh.
+	Shell string `yaml:"shell"`
+
+	// Timeout limits the total running time of the cmds, e.g. "10m"
+	Timeout time.Duration `yaml:"timeout"`
+	// Env is the extra environment variables for the cmds
+	Env map[string]string `yaml:"env"`
+	// WorkingDir is the directory relative to the repo root to run the cmds in
+	WorkingDir string `yaml:"working_dir"`
+	// AllowFailure reports a failed test as neutral instead of failure
+	AllowFailure bool `yaml:"allow_failure"`
+	// Limits limits the resources used by the cmds, bounded by the worker limits
+	Limits config.ResourceLimits `yaml:"limits"`
+
+	// Stage is one of the project stages, the test needs all the tests of the previous stages
+	Stage string `yaml:"stage"`
+	// Needs lists the tests which must pass before running this test
+	Needs []string `yaml:"needs"`
+	// Matrix expands the test into one test per combination of the values,
+	// the values are exposed as environment variables
+	Matrix map[string][]string `yaml:"matrix"`
+	// Paths only runs the test in pull requests which change the matched files
+	Paths []string `yaml:"paths"`
+	// PathsIgnore skips the test in pull requests which only change the matched files
+	PathsIgnore []string `yaml:"paths_ignore"`
+	// Artifacts are the files to archive after the test, e.g. "dist/**", "coverage.html"
+	Artifacts []string `yaml:"artifacts"`
+	// Matchers turn the matched lines of the output into annotations
+	Matchers []ProblemMatcher `yaml:"matchers"`
+}
+
+// ProblemMatcher matches the problems in the test output by a regexp with the
+// named groups: file, line, column, severity and message, in which file, line
+// and message are required.
+type ProblemMatcher struct {
+	Regexp string `yaml:"regexp"`
+	// Severity is the default severity: error, warning or notice
+	Severity string `yaml:"severity"`
+}
+
+// Compile compiles the regexp of the matcher, and checks the required groups
+func (m ProblemMatcher) Compile() (*regexp.Regexp, error) {
+	r, err := regexp.Compile(m.Regexp)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, name := range r.SubexpNames() {
+		names[name] = true
+	}
+	for _, name := range []string{"file", "line", "message"} {
+		if !names[name] {
+			return nil, fmt.Errorf("regexp %q has no group named %q", m.Regexp, name)
+		}
+	}
+	return r, nil
+}
+
+// MatchPaths checks if any of the changed files is relevant to the test
+func (t TestsConfig) MatchPaths(files []string) bool {
+	if len(t.Paths) == 0 && len(t.PathsIgnore) == 0 {
+		return true
+	}
+	for _, file := range files {
+		if len(t.Paths) > 0 && !MatchAny(t.Paths, file) {
+			continue
+		}
+		if MatchAny(t.PathsIgnore, file) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// FilterTests returns the tests relevant to the changed files and the reasons
+// of the skipped ones, the tests needing a skipped test are skipped as well
+func FilterTests(tests map[string]TestsConfig, files []string) (map[string]TestsConfig, map[string]string) {
+	skipped := make(map[string]string)
+	for name, test := range tests {
+		if !test.MatchPaths(files) {
+			skipped[name] = "no relevant files changed"
+		}
+	}
+	if len(skipped) == 0 {
+		return tests, nil
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, test := range tests {
+			if _, ok := skipped[name]; ok {
+				continue
+			}
+			for _, need := range test.Needs {
+				if _, ok := skipped[need]; ok {
+					skipped[name] = "needs " + need + " which was skipped"
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	relevant := make(map[string]TestsConfig, len(tests)-len(skipped))
+	for name, test := range tests {
+		if _, ok := skipped[name]; !ok {
+			relevant[name] = test
+		}
+	}
+	return relevant, skipped
+}
+
+// CommandShell returns the shell to run the i-th of the Commands in, empty for
+// none, only the script is run in sh by default
+func (t TestsConfig) CommandShell(i int) string {
+	switch t.Shell {
+	case "none":
+		return ""
+	case "":
+		if t.Script != "" && i == len(t.Cmds) {
+			return "sh"
+		}
+	}
+	return t.Shell
+}
+
+// Commands returns the commands to run, the script is the last one
+func (t TestsConfig) Commands() []string {
+	if t.Script == "" {
+		return t.Cmds
+	}
+	return append(append([]string(nil), t.Cmds...), t.Script)
+}
+
+// validate checks the options of the test
+func (t TestsConfig) validate() error {
+	switch t.Shell {
+	case "", "none", "bash", "sh":
+	default:
+		return fmt.Errorf("unknown shell %q", t.Shell)
+	}
+	if t.Shell == "none" && t.Script != "" {
+		return errors.New("script requires a shell")
+	}
+	for _, m := range t.Matchers {
+		if _, err := m.Compile(); err != nil {
+			return fmt.Errorf("invalid matcher: %v", err)
+		}
+	}
+	return nil
+}
+
+// Environ returns the extra environment variables of the test in the form "key=value"
+func (t TestsConfig) Environ() []string {
+	if len(t.Env) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(t.Env))
+	for k := range t.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := make([]string, 0, len(keys))
+	for _, k := range keys {
+		env = append(env, k+"="+t.Env[k])
+	}
+	return env
+}
+
+// Dir returns the absolute directory to run the test in
+func (t TestsConfig) Dir(repoPath string) (string, error) {
+	if t.WorkingDir == "" {
+		return repoPath, nil
+	}
+	dir, ok := joinRepoPath(repoPath, t.WorkingDir)
+	if !ok {
+		return "", fmt.Errorf("working_dir %q is outside of the repo", t.WorkingDir)
+	}
+	return dir, nil
+}
+
+// joinRepoPath joins the relative path to the repo root, and reports whether
+// the result is still inside of the repo
+func joinRepoPath(repoPath, path string) (string, bool) {
+	joined := filepath.Join(repoPath, path)
+	rel, err := filepath.Rel(repoPath, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return joined, false
+	}
+	return joined, true
 }
 
 // ReadProjectConfig get project config from CI config file
@@ -54,5 +284,225 @@ func ReadProjectConfig(cwd string) (config ProjectConfig, err error) {
 			config.Tests[k] = TestsConfig{Cmds: v, Coverage: ""}
 		}
 	}
+	for name, test := range config.Tests {
+		if err = test.validate(); err != nil {
+			return config, fmt.Errorf("test %q: %v", name, err)
+		}
+	}
+	if err = config.Benchmarks.validate(); err != nil {
+		return config, fmt.Errorf("benchmarks: %v", err)
+	}
+	if err = config.Vulnerability.validate(); err != nil {
+		return config, fmt.Errorf("vulnerability: %v", err)
+	}
+	if err = config.License.validate(); err != nil {
+		return config, fmt.Errorf("license: %v", err)
+	}
+	if err = config.Outdated.validate(); err != nil {
+		return config, fmt.Errorf("outdated: %v", err)
+	}
+	if err = config.SecretScan.validate(); err != nil {
+		return config, fmt.Errorf("secret_scan: %v", err)
+	}
+	if err = config.Security.validate(); err != nil {
+		return config, fmt.Errorf("security: %v", err)
+	}
+	err = config.expandMatrix()
+	if err != nil {
+		return config, err
+	}
+	err = config.resolveStages()
+	if err != nil {
+		return config, err
+	}
+	_, err = SortTests(config.Tests)
+	if err != nil {
+		return config, err
+	}
 	return config, nil
 }
+
+// matrixCombinations returns all the combinations of the matrix values
+func matrixCombinations(matrix map[string][]string) []map[string]string {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	combinations := []map[string]string{{}}
+	for _, k := range keys {
+		var next []map[string]string
+		for _, c := range combinations {
+			for _, v := range matrix[k] {
+				combination := make(map[string]string, len(c)+1)
+				for ck, cv := range c {
+					combination[ck] = cv
+				}
+				combination[k] = v
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// matrixTestName returns the test name of a matrix combination, e.g. "go (1.13, mysql)"
+func matrixTestName(name string, combination map[string]string) string {
+	keys := make([]string, 0, len(combination))
+	for k := range combination {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = combination[k]
+	}
+	return name + " (" + strings.Join(values, ", ") + ")"
+}
+
+// expandMatrix replaces each matrix test with one test per combination
+func (config *ProjectConfig) expandMatrix() error {
+	expanded := make(map[string][]string)
+	tests := make(map[string]TestsConfig, len(config.Tests))
+	for name, test := range config.Tests {
+		if len(test.Matrix) == 0 {
+			tests[name] = test
+			continue
+		}
+		for k, values := range test.Matrix {
+			if len(values) == 0 {
+				return fmt.Errorf("test %q: matrix %q has no values", name, k)
+			}
+			// the same values would expand to the same test
+			seen := make(map[string]bool, len(values))
+			for _, v := range values {
+				if seen[v] {
+					return fmt.Errorf("test %q: matrix %q has duplicate value %q", name, k, v)
+				}
+				seen[v] = true
+			}
+		}
+		for _, combination := range matrixCombinations(test.Matrix) {
+			t := test
+			t.Matrix = nil
+			t.Env = make(map[string]string, len(test.Env)+len(combination))
+			for k, v := range test.Env {
+				t.Env[k] = v
+			}
+			for k, v := range combination {
+				t.Env[k] = v
+			}
+			testName := matrixTestName(name, combination)
+			_, conflicts := config.Tests[testName]
+			if _, ok := tests[testName]; ok || conflicts {
+				return fmt.Errorf("test %q: matrix test name %q conflicts", name, testName)
+			}
+			tests[testName] = t
+			expanded[name] = append(expanded[name], testName)
+		}
+		sort.Strings(expanded[name])
+	}
+	// needing a matrix test means needing all of its combinations
+	for name, test := range tests {
+		var needs []string
+		for _, need := range test.Needs {
+			if names, ok := expanded[need]; ok {
+				needs = append(needs, names...)
+			} else {
+				needs = append(needs, need)
+			}
+		}
+		test.Needs = needs
+		tests[name] = test
+	}
+	config.Tests = tests
+	return nil
+}
+
+// resolveStages adds the tests of the previous stages to the needs of each staged test
+func (config *ProjectConfig) resolveStages() error {
+	stageIndex := make(map[string]int, len(config.Stages))
+	for i, stage := range config.Stages {
+		stageIndex[stage] = i
+	}
+	stageTests := make([][]string, len(config.Stages))
+	for name, test := range config.Tests {
+		if test.Stage == "" {
+			continue
+		}
+		i, ok := stageIndex[test.Stage]
+		if !ok {
+			return fmt.Errorf("test %q: unknown stage %q", name, test.Stage)
+		}
+		stageTests[i] = append(stageTests[i], name)
+	}
+	for name, test := range config.Tests {
+		if test.Stage == "" {
+			continue
+		}
+		needs := append([]string(nil), test.Needs...)
+		for i := 0; i < stageIndex[test.Stage]; i++ {
+			needs = append(needs, stageTests[i]...)
+		}
+		sort.Strings(needs)
+		test.Needs = uniqueStrings(needs)
+		config.Tests[name] = test
+	}
+	return nil
+}
+
+func uniqueStrings(s []string) []string {
+	var result []string
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// SortTests returns the test names in dependency order,
+// it fails if a test needs an unknown test or the needs are circular.
+func SortTests(tests map[string]TestsConfig) ([]string, error) {
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(tests))
+	order := make([]string, 0, len(tests))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("circular needs: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, need := range tests[name].Needs {
+			if _, ok := tests[need]; !ok {
+				return fmt.Errorf("test %q needs unknown test %q", name, need)
+			}
+			if err := visit(need, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
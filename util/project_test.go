@@ -1,12 +1,17 @@
 package util
 
 import (
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestReadProjectConfig(t *testing.T) {
@@ -28,3 +33,270 @@ func TestReadProjectConfig(t *testing.T) {
 		"sdk/**",
 	}, repoConf.IgnorePatterns)
 }
+
+func TestTestsConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var cfg TestsConfig
+	require.NoError(yaml.Unmarshal([]byte(`
+timeout: 10m
+env:
+  B: '2'
+  A: '1'
+working_dir: sub
+allow_failure: true
+`), &cfg))
+	assert.Equal(10*time.Minute, cfg.Timeout)
+	assert.Equal([]string{"A=1", "B=2"}, cfg.Environ())
+	assert.True(cfg.AllowFailure)
+
+	dir, err := cfg.Dir("/repo")
+	require.NoError(err)
+	assert.Equal("/repo/sub", dir)
+
+	cfg.WorkingDir = "../other"
+	_, err = cfg.Dir("/repo")
+	assert.Error(err)
+}
+
+func TestTestsNeeds(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+stages:
+  - build
+  - test
+  - e2e
+tests:
+  build:
+    stage: build
+    cmds: ['make']
+  unit:
+    stage: test
+    cmds: ['make test']
+  lint:
+    stage: test
+    cmds: ['make lint']
+  e2e:
+    stage: e2e
+    cmds: ['make e2e']
+  docs:
+    needs: ['build']
+    cmds: ['make docs']
+`), 0644))
+	repoConf, err := ReadProjectConfig(dir)
+	require.NoError(err)
+	assert.Equal([]string{"build"}, repoConf.Tests["unit"].Needs)
+	assert.Equal([]string{"build", "lint", "unit"}, repoConf.Tests["e2e"].Needs)
+	assert.Equal([]string{"build"}, repoConf.Tests["docs"].Needs)
+	assert.Empty(repoConf.Tests["build"].Needs)
+
+	order, err := SortTests(repoConf.Tests)
+	require.NoError(err)
+	assert.Equal([]string{"build", "docs", "lint", "unit", "e2e"}, order)
+
+	_, err = SortTests(map[string]TestsConfig{
+		"a": {Needs: []string{"b"}},
+		"b": {Needs: []string{"a"}},
+	})
+	assert.EqualError(err, "circular needs: a -> b -> a")
+
+	_, err = SortTests(map[string]TestsConfig{
+		"a": {Needs: []string{"c"}},
+	})
+	assert.Error(err)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+tests:
+  build:
+    stage: build
+    cmds: ['make']
+`), 0644))
+	_, err = ReadProjectConfig(dir)
+	assert.Error(err)
+}
+
+func TestTestsMatrix(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+tests:
+  go:
+    cmds: ['go test ./...']
+    coverage: 'coverage: (\d+\.\d+)%'
+    env:
+      CGO_ENABLED: '0'
+    matrix:
+      GO_VERSION: ['1.13', '1.14']
+      DB: [mysql, postgres]
+  deploy:
+    needs: ['go']
+    cmds: ['make deploy']
+`), 0644))
+	repoConf, err := ReadProjectConfig(dir)
+	require.NoError(err)
+	require.Len(repoConf.Tests, 5)
+	assert.NotContains(repoConf.Tests, "go")
+
+	test, ok := repoConf.Tests["go (mysql, 1.14)"]
+	require.True(ok)
+	assert.Nil(test.Matrix)
+	assert.Equal(`coverage: (\d+\.\d+)%`, test.Coverage)
+	assert.Equal([]string{"CGO_ENABLED=0", "DB=mysql", "GO_VERSION=1.14"}, test.Environ())
+
+	assert.Equal([]string{
+		"go (mysql, 1.13)", "go (mysql, 1.14)",
+		"go (postgres, 1.13)", "go (postgres, 1.14)",
+	}, repoConf.Tests["deploy"].Needs)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+tests:
+  go:
+    cmds: ['go test ./...']
+    matrix:
+      GO_VERSION: []
+`), 0644))
+	_, err = ReadProjectConfig(dir)
+	assert.Error(err)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+tests:
+  go:
+    cmds: ['go test ./...']
+    matrix:
+      GO_VERSION: ['1.13', '1.14', '1.13']
+`), 0644))
+	_, err = ReadProjectConfig(dir)
+	assert.EqualError(err, `test "go": matrix "GO_VERSION" has duplicate value "1.13"`)
+}
+
+func TestFilterTests(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := map[string]TestsConfig{
+		"go":     {Paths: []string{"**/*.go", "go.mod"}},
+		"web":    {Paths: []string{"web/**"}, PathsIgnore: []string{"web/**/*.md"}},
+		"all":    {PathsIgnore: []string{"**/*.md"}},
+		"deploy": {Needs: []string{"go", "web"}},
+	}
+	assert.True(tests["deploy"].MatchPaths(nil))
+	assert.False(tests["go"].MatchPaths(nil))
+
+	relevant, skipped := FilterTests(tests, []string{"README.md", "web/docs/intro.md"})
+	assert.Equal(map[string]string{
+		"all":    "no relevant files changed",
+		"go":     "no relevant files changed",
+		"web":    "no relevant files changed",
+		"deploy": "needs go which was skipped",
+	}, skipped)
+	assert.Empty(relevant)
+
+	relevant, skipped = FilterTests(tests, []string{"web/index.html", "util/util.go"})
+	assert.Empty(skipped)
+	assert.Equal(tests, relevant)
+
+	relevant, skipped = FilterTests(tests, []string{"cmd/main.go"})
+	assert.Equal(map[string]string{
+		"web":    "no relevant files changed",
+		"deploy": "needs web which was skipped",
+	}, skipped)
+	assert.Equal(map[string]TestsConfig{"all": tests["all"], "go": tests["go"]}, relevant)
+
+	// the dependents are skipped transitively
+	tests["release"] = TestsConfig{Needs: []string{"all", "deploy"}}
+	relevant, skipped = FilterTests(tests, []string{"cmd/main.go"})
+	assert.Equal("needs deploy which was skipped", skipped["release"])
+	assert.Equal(map[string]TestsConfig{"all": tests["all"], "go": tests["go"]}, relevant)
+}
+
+func TestBenchmarksConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var conf ProjectConfig
+	require.NoError(yaml.Unmarshal([]byte(`
+benchmarks:
+  cmds: ['go test -run=^$ -bench=. -count=5 ./...']
+  timeout: 30m
+  threshold: 10
+`), &conf))
+	assert.True(conf.Benchmarks.Enabled())
+	assert.Equal([]string{"go test -run=^$ -bench=. -count=5 ./..."}, conf.Benchmarks.Cmds)
+	assert.Equal(30*time.Minute, conf.Benchmarks.Timeout)
+	assert.Equal(10.0, conf.Benchmarks.Threshold)
+	assert.False(BenchmarksConfig{}.Enabled())
+}
+
+func TestProblemMatcher(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, err := ProblemMatcher{Regexp: `^(?P<file>\S+):(?P<line>\d+): (?P<message>.*)$`}.Compile()
+	assert.NoError(err)
+	_, err = ProblemMatcher{Regexp: `^(?P<file>\S+): (?P<message>.*)$`}.Compile()
+	assert.EqualError(err, `regexp "^(?P<file>\\S+): (?P<message>.*)$" has no group named "line"`)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+tests:
+  check:
+    cmds: ['make check']
+    matchers:
+      - regexp: '^(?P<file>[^:]+):(?P<line>\d+'
+`), 0644))
+	_, err = ReadProjectConfig(dir)
+	assert.Error(err)
+}
+
+func TestTestsShell(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	test := TestsConfig{Cmds: []string{"make"}}
+	assert.Equal("", test.CommandShell(0))
+	assert.Equal([]string{"make"}, test.Commands())
+	test.Script = "make check\nmake install\n"
+	// the cmds are still run directly
+	assert.Equal("", test.CommandShell(0))
+	assert.Equal("sh", test.CommandShell(1))
+	assert.Equal([]string{"make", "make check\nmake install\n"}, test.Commands())
+	test.Shell = "bash"
+	assert.Equal("bash", test.CommandShell(0))
+	assert.Equal("bash", test.CommandShell(1))
+	assert.NoError(test.validate())
+	test.Shell = "none"
+	assert.EqualError(test.validate(), "script requires a shell")
+	test.Shell = "zsh"
+	assert.EqualError(test.validate(), `unknown shell "zsh"`)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+tests:
+  check:
+    shell: bash
+    script: |
+      make
+      make check
+`), 0644))
+	config, err := ReadProjectConfig(dir)
+	require.NoError(err)
+	assert.Equal("make\nmake check\n", config.Tests["check"].Script)
+	assert.Equal("bash", config.Tests["check"].CommandShell(0))
+}
@@ -0,0 +1,192 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// secretMask replaces the secrets in the logs
+const secretMask = "***"
+
+var secretNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidSecretName checks if the name can be used as an environment variable
+func ValidSecretName(name string) bool {
+	return secretNameRegexp.MatchString(name)
+}
+
+// ParseSecretsKey decodes the base64 encoded AES-256 key of the secrets
+func ParseSecretsKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("secrets key is not configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid secrets key: got %d bytes, want 32", len(key))
+	}
+	return key, nil
+}
+
+// EncryptSecret encrypts the value with AES-GCM, the nonce is prepended to the result
+func EncryptSecret(key []byte, value string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, []byte(value), nil), nil
+}
+
+// DecryptSecret decrypts the data encrypted by EncryptSecret
+func DecryptSecret(key []byte, data []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid secret data")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	value, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SecretsEnviron returns the secrets in the form "key=value"
+func SecretsEnviron(secrets map[string]string) []string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, name+"="+secrets[name])
+	}
+	return env
+}
+
+// secretLineMin is the minimum length of the lines of the multi-line secrets
+// masked on their own, not to mask the brackets of JSON for example
+const secretLineMin = 4
+
+// secretValues returns the non-empty values, the longer ones first so that
+// a secret containing another one is masked entirely. The lines of the
+// multi-line secrets are returned as well, as the output is masked line by
+// line by MaskWriter.
+func secretValues(secrets map[string]string) []string {
+	values := make([]string, 0, len(secrets))
+	seen := make(map[string]bool, len(secrets))
+	add := func(v string) {
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	for _, v := range secrets {
+		if v == "" {
+			continue
+		}
+		add(v)
+		if !strings.Contains(v, "\n") {
+			continue
+		}
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); len(line) >= secretLineMin {
+				add(line)
+			}
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	return values
+}
+
+// MaskSecrets replaces the values of the secrets in s
+func MaskSecrets(s string, secrets map[string]string) string {
+	for _, v := range secretValues(secrets) {
+		s = strings.Replace(s, v, secretMask, -1)
+	}
+	return s
+}
+
+// MaskWriter masks the secrets written to the underlying writer line by line,
+// Flush must be called to write the last incomplete line.
+type MaskWriter struct {
+	w      io.Writer
+	values []string
+	buf    []byte
+	mu     sync.Mutex
+}
+
+// NewMaskWriter returns a new MaskWriter
+func NewMaskWriter(w io.Writer, secrets map[string]string) *MaskWriter {
+	return &MaskWriter{
+		w:      w,
+		values: secretValues(secrets),
+	}
+}
+
+// Write writes the complete lines in p with the secrets masked
+func (m *MaskWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.values) == 0 {
+		return m.w.Write(p)
+	}
+	m.buf = append(m.buf, p...)
+	i := bytes.LastIndexByte(m.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	err := m.write(m.buf[:i+1])
+	m.buf = append(m.buf[:0], m.buf[i+1:]...)
+	return len(p), err
+}
+
+// Flush writes the buffered incomplete line
+func (m *MaskWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.buf) == 0 {
+		return nil
+	}
+	err := m.write(m.buf)
+	m.buf = m.buf[:0]
+	return err
+}
+
+func (m *MaskWriter) write(p []byte) error {
+	for _, v := range m.values {
+		p = bytes.Replace(p, []byte(v), []byte(secretMask), -1)
+	}
+	_, err := m.w.Write(p)
+	return err
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, err := ParseSecretsKey("")
+	assert.Error(err)
+	_, err = ParseSecretsKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.EqualError(err, "invalid secrets key: got 5 bytes, want 32")
+
+	key, err := ParseSecretsKey(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	require.NoError(err)
+
+	data, err := EncryptSecret(key, "s3cr3t")
+	require.NoError(err)
+	assert.NotContains(string(data), "s3cr3t")
+	value, err := DecryptSecret(key, data)
+	require.NoError(err)
+	assert.Equal("s3cr3t", value)
+
+	other := bytes.Repeat([]byte{2}, 32)
+	_, err = DecryptSecret(other, data)
+	assert.Error(err)
+	_, err = DecryptSecret(key, data[:4])
+	assert.Error(err)
+}
+
+func TestValidSecretName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(ValidSecretName("API_TOKEN"))
+	assert.True(ValidSecretName("_token1"))
+	assert.False(ValidSecretName("1TOKEN"))
+	assert.False(ValidSecretName("API-TOKEN"))
+	assert.False(ValidSecretName(""))
+}
+
+func TestMaskSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	secrets := map[string]string{"A": "abc", "B": "abcdef", "C": ""}
+	assert.Equal("token: *** and ***\n", MaskSecrets("token: abcdef and abc\n", secrets))
+	assert.Equal([]string{"A=abc", "B=abcdef", "C="}, SecretsEnviron(secrets))
+
+	var b bytes.Buffer
+	w := NewMaskWriter(&b, secrets)
+	_, _ = w.Write([]byte("first ab"))
+	assert.Empty(b.String())
+	_, _ = w.Write([]byte("cdef\nsecond a"))
+	assert.Equal("first ***\n", b.String())
+	_, _ = w.Write([]byte("bc"))
+	assert.NoError(w.Flush())
+	assert.Equal("first ***\nsecond ***", b.String())
+
+	// the lines of a multi-line secret are masked on their own
+	b.Reset()
+	key := "-----BEGIN KEY-----\nMIIBOgIBAAJBAKj34GkxFhD90vcNLYLInFEX\n-----END KEY-----\n"
+	w = NewMaskWriter(&b, map[string]string{"KEY": key, "JSON": "{\n  \"token\": \"abcdef\"\n}"})
+	for _, line := range strings.SplitAfter("key: "+key+"json: {\n  \"token\": \"abcdef\"\n}\n", "\n") {
+		_, _ = w.Write([]byte(line))
+	}
+	assert.NoError(w.Flush())
+	assert.Equal("key: ***\n***\n***\njson: {\n  ***\n}\n", b.String())
+	assert.Equal("key: ***", MaskSecrets("key: "+key, map[string]string{"KEY": key}))
+
+	b.Reset()
+	w = NewMaskWriter(&b, nil)
+	_, _ = w.Write([]byte("no secrets"))
+	assert.Equal("no secrets", b.String())
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretScanConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c := SecretScanConfig{
+		Rules: []SecretRule{
+			{ID: "internal-token", Regexp: `itk_(?P<secret>[0-9a-f]{32})`, Entropy: 3},
+		},
+		Allowlist: SecretAllowlist{Regexps: []string{"^itk_0+$"}},
+	}
+	assert.NoError(c.validate())
+
+	c.Rules = append(c.Rules, SecretRule{ID: "internal-token", Regexp: "x"})
+	assert.EqualError(c.validate(), `duplicated rule "internal-token"`)
+	c.Rules[1] = SecretRule{ID: "bad", Regexp: "("}
+	assert.EqualError(c.validate(), "rule \"bad\": error parsing regexp: missing closing ): `(`")
+	c.Rules[1] = SecretRule{Regexp: "x"}
+	assert.EqualError(c.validate(), "rule id is required")
+	c.Rules[1] = SecretRule{ID: "empty"}
+	assert.EqualError(c.validate(), `rule "empty": regexp is required`)
+
+	c = SecretScanConfig{Allowlist: SecretAllowlist{Regexps: []string{"["}}}
+	assert.EqualError(c.validate(), "allowlist: error parsing regexp: missing closing ]: `[`")
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var cweRegexp = regexp.MustCompile(`^CWE-[0-9]+$`)
+
+// SecurityConfig config for the static security analysis, it is opt-in as the
+// Go packages are type-checked from the source
+type SecurityConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// DisabledRules are the IDs of the built-in rules not to apply
+	DisabledRules []string `yaml:"disabled_rules"`
+	// PHPRules are the custom rules of the taint-prone PHP patterns
+	PHPRules []SecurityRule `yaml:"php_rules"`
+}
+
+// SecurityRule flags the lines matched by the regexp
+type SecurityRule struct {
+	ID string `yaml:"id"`
+	// CWE is the weakness ID like "CWE-89"
+	CWE string `yaml:"cwe"`
+	// Severity is high, medium or low, medium by default
+	Severity string `yaml:"severity"`
+	Regexp   string `yaml:"regexp"`
+	Message  string `yaml:"message"`
+}
+
+// RuleDisabled reports whether the rule is disabled
+func (c SecurityConfig) RuleDisabled(id string) bool {
+	for _, r := range c.DisabledRules {
+		if r == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (c SecurityConfig) validate() error {
+	for _, r := range c.PHPRules {
+		if r.ID == "" {
+			return errors.New("rule id is required")
+		}
+		if _, err := regexp.Compile(r.Regexp); err != nil || r.Regexp == "" {
+			return fmt.Errorf("rule %q: invalid regexp %q", r.ID, r.Regexp)
+		}
+		if r.CWE != "" && !cweRegexp.MatchString(r.CWE) {
+			return fmt.Errorf("rule %q: invalid cwe %q", r.ID, r.CWE)
+		}
+		switch r.Severity {
+		case "", "high", "medium", "low":
+		default:
+			return fmt.Errorf("rule %q: unknown severity %q", r.ID, r.Severity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c := SecurityConfig{
+		DisabledRules: []string{"weakhash"},
+		PHPRules: []SecurityRule{
+			{ID: "php-assert", CWE: "CWE-95", Severity: "high", Regexp: `\bassert\s*\(\s*\$`},
+		},
+	}
+	assert.NoError(c.validate())
+	assert.True(c.RuleDisabled("weakhash"))
+	assert.False(c.RuleDisabled("sqlinjection"))
+
+	c.PHPRules[0].CWE = "89"
+	assert.EqualError(c.validate(), `rule "php-assert": invalid cwe "89"`)
+	c.PHPRules[0].CWE = ""
+	c.PHPRules[0].Severity = "critical"
+	assert.EqualError(c.validate(), `rule "php-assert": unknown severity "critical"`)
+	c.PHPRules[0].Severity = ""
+	c.PHPRules[0].Regexp = "("
+	assert.EqualError(c.validate(), `rule "php-assert": invalid regexp "("`)
+	c.PHPRules[0].ID = ""
+	assert.EqualError(c.validate(), "rule id is required")
+}
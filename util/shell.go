@@ -3,6 +3,8 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 
 	shellwords "github.com/mattn/go-shellwords"
 	"github.com/tengattack/unified-ci/common"
@@ -15,23 +17,94 @@ func NewShellParser(repoPath string, ref common.GithubRef) *shellwords.Parser {
 	parser.ParseBacktick = true
 	parser.Dir = repoPath
 
-	projectName := filepath.Base(repoPath)
-
+	env := ciEnv(repoPath, ref)
 	parser.Getenv = func(key string) string {
-		switch key {
-		case "PWD":
+		if key == "PWD" {
 			return repoPath
-		case "PROJECT_NAME":
-			return projectName
-		case "CI_CHECK_TYPE":
-			return ref.CheckType
-		case "CI_CHECK_REF":
-			return ref.CheckRef
-		case "BASE_COMMIT":
-			return ref.BaseSha
+		}
+		if v, ok := env[key]; ok {
+			return v
 		}
 		return os.Getenv(key)
 	}
 
 	return parser
 }
+
+// ciVariables are the names of the CI variables set by ciEnv
+var ciVariables = map[string]bool{
+	"CI": true, "PROJECT_NAME": true, "CI_CHECK_TYPE": true, "CI_CHECK_REF": true,
+	"BASE_COMMIT": true, "CI_COMMIT_SHA": true, "CI_PR_NUMBER": true, "CI_BASE_BRANCH": true,
+	"CI_HEAD_BRANCH": true, "CI_AUTHOR": true, "CI_REPOSITORY": true, "CI_LOG_URL": true,
+	"CI_WORKER_NAME": true,
+}
+
+// IsCIVariable reports whether the key is one of the CI variables, which the
+// env of the project config, the tests and the secrets cannot override
+func IsCIVariable(key string) bool {
+	return ciVariables[key]
+}
+
+// ciEnv returns the env from the project config with the CI variables of the
+// ref, see README.md for the details
+func ciEnv(repoPath string, ref common.GithubRef) map[string]string {
+	env := make(map[string]string, len(ref.Env)+13)
+	for k, v := range ref.Env {
+		env[k] = v
+	}
+	env["CI"] = "true"
+	// the repo may be checked out in a worktree like the base one
+	env["PROJECT_NAME"] = ref.RepoName
+	if ref.RepoName == "" {
+		env["PROJECT_NAME"] = filepath.Base(repoPath)
+	}
+	env["CI_CHECK_TYPE"] = ref.CheckType
+	env["CI_CHECK_REF"] = ref.CheckRef
+	env["BASE_COMMIT"] = ref.BaseSha
+	env["CI_COMMIT_SHA"] = ref.Sha
+	env["CI_PR_NUMBER"] = ""
+	if ref.PRNum > 0 {
+		env["CI_PR_NUMBER"] = strconv.Itoa(ref.PRNum)
+	}
+	env["CI_BASE_BRANCH"] = ref.BaseBranch
+	env["CI_HEAD_BRANCH"] = ref.HeadBranch
+	env["CI_AUTHOR"] = ref.Author
+	env["CI_REPOSITORY"] = ref.Owner + "/" + ref.RepoName
+	env["CI_LOG_URL"] = ref.LogURL
+	env["CI_WORKER_NAME"] = common.Conf.Worker.Name
+	return env
+}
+
+// CIEnviron returns the CI variables of the ref in the form "key=value"
+func CIEnviron(repoPath string, ref common.GithubRef) []string {
+	env := ciEnv(repoPath, ref)
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	environ := make([]string, 0, len(keys))
+	for _, k := range keys {
+		environ = append(environ, k+"="+env[k])
+	}
+	return environ
+}
+
+// Environ returns the environment of the commands run for the ref, which is the
+// env of the current process with the CI variables and extra "key=value" env
+func Environ(repoPath string, ref common.GithubRef, extra ...string) []string {
+	env := append(os.Environ(), CIEnviron(repoPath, ref)...)
+	return append(env, extra...)
+}
+
+// ShellCommand returns the command running the script in the shell with
+// fail-fast semantics like "set -eo pipefail"
+func ShellCommand(shell, script string) (string, []string) {
+	switch shell {
+	case "bash":
+		return "bash", []string{"--noprofile", "--norc", "-eo", "pipefail", "-c", script}
+	default:
+		// pipefail is not supported by all the sh
+		return shell, []string{"-e", "-c", "if (set -o pipefail) 2>/dev/null; then set -o pipefail; fi\n" + script}
+	}
+}
@@ -28,3 +28,50 @@ func TestNewShellParser(t *testing.T) {
 	require.NoError(err)
 	assert.Equal([]string{"echo", currentDir, "util", common.CheckTypeBranch, "stable"}, words)
 }
+
+func TestCIEnviron(t *testing.T) {
+	assert := assert.New(t)
+
+	ref := common.GithubRef{
+		CheckType:  common.CheckTypePRHead,
+		CheckRef:   "pr/1",
+		PRNum:      1,
+		BaseBranch: "master",
+		HeadBranch: "feature",
+		Author:     "octocat",
+		LogURL:     "https://ci.example.com/logs/owner/repo/abc.log",
+		Env:        map[string]string{"FOO": "bar", "CI": "false"},
+		Owner:      "owner",
+		RepoName:   "repo",
+		BaseSha:    "def",
+		Sha:        "abc",
+	}
+	// the base worktree is not named after the repo
+	env := CIEnviron("/tmp/unified-ci-base-123456", ref)
+	assert.Contains(env, "CI=true")
+	assert.Contains(env, "FOO=bar")
+	assert.Contains(env, "PROJECT_NAME=repo")
+	assert.Contains(env, "CI_COMMIT_SHA=abc")
+	assert.Contains(env, "CI_PR_NUMBER=1")
+	assert.Contains(env, "CI_BASE_BRANCH=master")
+	assert.Contains(env, "CI_HEAD_BRANCH=feature")
+	assert.Contains(env, "CI_AUTHOR=octocat")
+	assert.Contains(env, "CI_REPOSITORY=owner/repo")
+	assert.Contains(env, "CI_LOG_URL=https://ci.example.com/logs/owner/repo/abc.log")
+	assert.Contains(env, "BASE_COMMIT=def")
+	assert.NotContains(env, "CI=false")
+
+	parser := NewShellParser("/tmp/repo", ref)
+	words, err := parser.Parse("echo $CI_PR_NUMBER $FOO")
+	assert.NoError(err)
+	assert.Equal([]string{"echo", "1", "bar"}, words)
+}
+
+func TestIsCIVariable(t *testing.T) {
+	assert := assert.New(t)
+
+	for k := range ciEnv("/tmp/repo", common.GithubRef{}) {
+		assert.True(IsCIVariable(k), k)
+	}
+	assert.False(IsCIVariable("FOO"))
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SizeConfig config for tracking the size of the build outputs
+type SizeConfig struct {
+	// Files maps the names to the glob patterns of the outputs,
+	// the size of a name is the total size of the matched files
+	Files map[string]string `yaml:"files"`
+	// MaxGrowth limits the growth of each size compared with base,
+	// in percent like "5%" or in bytes like "100K"
+	MaxGrowth string `yaml:"max_growth"`
+}
+
+// Enabled reports whether there is any output to measure
+func (c SizeConfig) Enabled() bool {
+	return len(c.Files) > 0
+}
+
+// Exceeds checks if the growth from base to head is more than MaxGrowth
+func (c SizeConfig) Exceeds(base, head int64) (bool, error) {
+	limit := strings.TrimSpace(c.MaxGrowth)
+	if limit == "" || head <= base {
+		return false, nil
+	}
+	if strings.HasSuffix(limit, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(limit, "%")), 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid max growth %q", c.MaxGrowth)
+		}
+		if base == 0 {
+			return true, nil
+		}
+		return float64(head-base)*100/float64(base) > pct, nil
+	}
+	n, err := ParseByteSize(limit)
+	if err != nil {
+		return false, fmt.Errorf("invalid max growth %q", c.MaxGrowth)
+	}
+	return uint64(head-base) > n, nil
+}
+
+// MeasureSizes returns the total size of the files matched by each pattern
+func MeasureSizes(repoPath string, files map[string]string) (map[string]int64, error) {
+	sizes := make(map[string]int64, len(files))
+	for name := range files {
+		sizes[name] = 0
+	}
+	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		for name, pattern := range files {
+			if MatchAny([]string{pattern}, rel) {
+				sizes[name] += info.Size()
+			}
+		}
+		return nil
+	})
+	return sizes, err
+}
+
+// FormatBytes formats the size like "1.50 MiB"
+func FormatBytes(n int64) string {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	if abs < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n) / 1024
+	i := 0
+	for ; i < len(units)-1 && (v >= 1024 || v <= -1024); i++ {
+		v /= 1024
+	}
+	return fmt.Sprintf("%.2f %s", v, units[i])
+}
+
+// DiffSizes generates a markdown table of the size deltas between head and base,
+// and returns the names whose growth exceeds the limit.
+func DiffSizes(c SizeConfig, head, base map[string]int64) (string, []string, error) {
+	names := make([]string, 0, len(head))
+	for name := range head {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var exceeded []string
+	table := "| name | base | head | delta |\n| ---- | ---- | ---- | ----- |\n"
+	for _, name := range names {
+		size := head[name]
+		baseSize, ok := base[name]
+		if !ok {
+			table += fmt.Sprintf("| %s | - | %s | - |\n", name, FormatBytes(size))
+			continue
+		}
+		delta := FormatBytes(size - baseSize)
+		if size > baseSize {
+			delta = "+" + delta
+		}
+		if baseSize > 0 {
+			delta += fmt.Sprintf(" (%+.2f%%)", float64(size-baseSize)*100/float64(baseSize))
+		}
+		exceeds, err := c.Exceeds(baseSize, size)
+		if err != nil {
+			return "", nil, err
+		}
+		if exceeds {
+			exceeded = append(exceeded, name)
+			delta = "**" + delta + "** ✖"
+		}
+		table += fmt.Sprintf("| %s | %s | %s | %s |\n", name, FormatBytes(baseSize), FormatBytes(size), delta)
+	}
+	return table, exceeded, nil
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasureSizes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	repoPath, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(repoPath)
+
+	require.NoError(os.MkdirAll(filepath.Join(repoPath, "dist", "js"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "dist", "js", "app.js"), make([]byte, 100), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "dist", "js", "vendor.js"), make([]byte, 50), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(repoPath, "dist", "app.css"), make([]byte, 10), 0644))
+
+	sizes, err := MeasureSizes(repoPath, map[string]string{
+		"js":   "dist/**/*.js",
+		"css":  "dist/*.css",
+		"none": "build/*.apk",
+	})
+	require.NoError(err)
+	assert.Equal(map[string]int64{"js": 150, "css": 10, "none": 0}, sizes)
+}
+
+func TestDiffSizes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	assert.Equal("512 B", FormatBytes(512))
+	assert.Equal("1.50 KiB", FormatBytes(1536))
+	assert.Equal("-2.00 MiB", FormatBytes(-2<<20))
+
+	c := SizeConfig{MaxGrowth: "10%"}
+	exceeds, err := c.Exceeds(1000, 1101)
+	require.NoError(err)
+	assert.True(exceeds)
+	exceeds, err = c.Exceeds(1000, 1100)
+	require.NoError(err)
+	assert.False(exceeds)
+	exceeds, err = SizeConfig{MaxGrowth: "1K"}.Exceeds(1000, 2025)
+	require.NoError(err)
+	assert.True(exceeds)
+	exceeds, err = SizeConfig{}.Exceeds(1000, 5000)
+	require.NoError(err)
+	assert.False(exceeds)
+	_, err = SizeConfig{MaxGrowth: "x%"}.Exceeds(1000, 5000)
+	assert.Error(err)
+
+	table, exceeded, err := DiffSizes(c,
+		map[string]int64{"app": 2048, "css": 1000, "new": 10},
+		map[string]int64{"app": 1024, "css": 1010})
+	require.NoError(err)
+	assert.Equal([]string{"app"}, exceeded)
+	assert.Equal("| name | base | head | delta |\n| ---- | ---- | ---- | ----- |\n"+
+		"| app | 1.00 KiB | 2.00 KiB | **+1.00 KiB (+100.00%)** ✖ |\n"+
+		"| css | 1010 B | 1000 B | -10 B (-0.99%) |\n"+
+		"| new | - | 10 B | - |\n", table)
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VulnerabilityIgnoreDateLayout is the layout of the until dates of the
+// suppressions
+const VulnerabilityIgnoreDateLayout = "2006-01-02"
+
+// VulnerabilityConfig config for the vulnerability check
+type VulnerabilityConfig struct {
+	// Ignore lists the accepted vulnerabilities, which do not fail the check
+	Ignore []VulnerabilityIgnore `yaml:"ignore"`
+}
+
+// VulnerabilityIgnore suppresses the vulnerabilities matching its ID or
+// package until the date
+type VulnerabilityIgnore struct {
+	// ID is the vulnerability ID like "CVE-2020-8203" or "GHSA-p6mc-m468-83gw"
+	ID string `yaml:"id"`
+	// Package is the name of the package whose vulnerabilities are all ignored
+	Package string `yaml:"package"`
+	// Reason justifies why the vulnerability does not affect the project
+	Reason string `yaml:"reason"`
+	// Until is the last day of the suppression like "2021-12-31", the
+	// suppression never expires if it is empty
+	Until string `yaml:"until"`
+}
+
+// UntilTime returns the time the suppression expires at, which is the end of
+// the Until day in local time, it is zero if the suppression never expires.
+func (i VulnerabilityIgnore) UntilTime() (time.Time, error) {
+	if i.Until == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(VulnerabilityIgnoreDateLayout, i.Until, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid until date %q, want YYYY-MM-DD", i.Until)
+	}
+	return t.AddDate(0, 0, 1), nil
+}
+
+// Expired reports whether the suppression has expired at now
+func (i VulnerabilityIgnore) Expired(now time.Time) bool {
+	t, err := i.UntilTime()
+	if err != nil || t.IsZero() {
+		return false
+	}
+	return !now.Before(t)
+}
+
+func (i VulnerabilityIgnore) validate() error {
+	if i.ID == "" && i.Package == "" {
+		return errors.New("id or package is required")
+	}
+	if strings.TrimSpace(i.Reason) == "" {
+		return errors.New("reason is required")
+	}
+	_, err := i.UntilTime()
+	return err
+}
+
+func (c VulnerabilityConfig) validate() error {
+	for n, i := range c.Ignore {
+		if err := i.validate(); err != nil {
+			return fmt.Errorf("ignore %d: %v", n+1, err)
+		}
+	}
+	return nil
+}
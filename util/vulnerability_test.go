@@ -0,0 +1,71 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVulnerabilityIgnore(t *testing.T) {
+	assert := assert.New(t)
+
+	i := VulnerabilityIgnore{ID: "CVE-2020-8203", Reason: "not reachable", Until: "2021-06-30"}
+	assert.NoError(i.validate())
+	assert.False(i.Expired(time.Date(2021, 6, 30, 23, 59, 0, 0, time.Local)))
+	assert.True(i.Expired(time.Date(2021, 7, 1, 0, 0, 0, 0, time.Local)))
+
+	i.Until = ""
+	assert.NoError(i.validate())
+	assert.False(i.Expired(time.Now()))
+
+	i.Until = "30/06/2021"
+	assert.EqualError(i.validate(), `invalid until date "30/06/2021", want YYYY-MM-DD`)
+	assert.False(i.Expired(time.Now()))
+
+	assert.EqualError(VulnerabilityIgnore{Reason: "unused"}.validate(), "id or package is required")
+	assert.EqualError(VulnerabilityIgnore{Package: "lodash"}.validate(), "reason is required")
+
+	c := VulnerabilityConfig{Ignore: []VulnerabilityIgnore{
+		{Package: "lodash", Reason: "dev only"},
+		{ID: "CVE-2020-8203"},
+	}}
+	assert.EqualError(c.validate(), "ignore 2: reason is required")
+}
+
+func TestReadVulnerabilityConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "unified-ci")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+vulnerability:
+  ignore:
+    - id: CVE-2020-8203
+      reason: zipObjectDeep is never called
+      until: 2021-06-30
+    - package: phpunit/phpunit
+      reason: dev only
+`), 0644))
+	config, err := ReadProjectConfig(dir)
+	require.NoError(err)
+	assert.Equal([]VulnerabilityIgnore{
+		{ID: "CVE-2020-8203", Reason: "zipObjectDeep is never called", Until: "2021-06-30"},
+		{Package: "phpunit/phpunit", Reason: "dev only"},
+	}, config.Vulnerability.Ignore)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, projectTestsConfigFile), []byte(`
+vulnerability:
+  ignore:
+    - id: CVE-2020-8203
+`), 0644))
+	_, err = ReadProjectConfig(dir)
+	assert.EqualError(err, "vulnerability: ignore 1: reason is required")
+}